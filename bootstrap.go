@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"final-project/models"
+	"flag"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// createSuperAdmin implements `create-superadmin -username -email -password`.
+// It only works while no superadmin exists; further promotions go through
+// the admin API.
+func createSuperAdmin(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("create-superadmin", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the superadmin")
+	email := fs.String("email", "", "email of the superadmin")
+	password := fs.String("password", "", "password of the superadmin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" || *password == "" {
+		return errors.New("-username, -email and -password are required")
+	}
+
+	var count int64
+	if err := db.Model(models.User{}).Where("role = ?", models.RoleSuperAdmin).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("a superadmin already exists, use the admin API to promote users")
+	}
+
+	u := models.User{Username: *username, Email: *email, Password: *password, Role: models.RoleCustomer}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := u.SaveUser(tx); err != nil {
+			return err
+		}
+		_, err := models.ChangeRole(tx, &u, models.RoleSuperAdmin, nil, models.RoleChangeByBootstrap, models.AuditMeta{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("superadmin %s (id %d) created\n", u.Username, u.ID)
+	return nil
+}
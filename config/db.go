@@ -27,7 +27,9 @@ func ConnectDataBase() *gorm.DB {
 		panic(err.Error())
 	}
 
-	db.AutoMigrate(&models.Restaurant{}, &models.Review{}, &models.User{}, &models.Menu{}, &models.OrderHistory{})
+	if err := models.Migrate(db); err != nil {
+		panic(err.Error())
+	}
 
 	return db
 }
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"final-project/models"
+	"final-project/principal"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DeleteAccountInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Export My Data
+// @Description Download everything stored about the logged in user: account, reviews, orders, restaurants, memberships, linked identities, API keys and role changes. format=zip returns one JSON file per section.
+// @Tags User
+// @Produce  json
+// @Produce  application/zip
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} map[string]any
+// @Router /user/me/export [get]
+func ExportAccount(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+		return
+	}
+
+	data, err := models.ExportUserData(db, u)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export data"})
+		return
+	}
+
+	filename := fmt.Sprintf("account-%d-%s", u.ID, time.Now().Format("20060102"))
+	if format == "json" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, gin.H{"status": "success", "data": data})
+		return
+	}
+
+	archive, err := zipSections(data)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export data"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// zipSections writes every section to <name>.json inside a zip archive.
+func zipSections(sections map[string]any) ([]byte, error) {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, name := range names {
+		f, err := w.Create(name + ".json")
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sections[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Delete My Account
+// @Description Schedule the account for deletion after ACCOUNT_DELETION_GRACE_DAYS. Until then the account works and the deletion can be cancelled. Afterwards personal data is erased, reviews and orders are kept anonymously.
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body DeleteAccountInput true "the body to delete the account"
+// @Success 200 {object} map[string]any
+// @Router /user/me/deletion [post]
+func RequestAccountDeletion(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input DeleteAccountInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := models.VerifyPassword(input.CurrentPassword, u.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current password incorrect"})
+		return
+	}
+
+	err := u.RequestDeletion(db)
+	if errors.Is(err, models.ErrDeletionAlreadyRequested) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to schedule deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "account deletion scheduled", "data": gin.H{"deletion_scheduled_at": u.DeletionScheduledAt}})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Cancel Account Deletion
+// @Description Cancel a scheduled deletion of the logged in user during the grace period
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} map[string]string
+// @Router /user/me/deletion [delete]
+func CancelAccountDeletion(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err := u.CancelDeletion(db)
+	if errors.Is(err, models.ErrDeletionNotRequested) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "account deletion cancelled"})
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"final-project/models"
+	"final-project/principal"
+	"final-project/utils/mailer"
+	"final-project/utils/query"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type InvitationInput struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Change User Role
+// @Description Change the platform role of a user (user, admin or superadmin), requires user:roles
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Param Body body RoleInput true "the body to change a role"
+// @Success 200 {object} map[string]models.RoleChange
+// @Router /admin/users/{id}/role [put]
+func ChangeUserRole(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input RoleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, u, ok := adminTarget(c, db)
+	if !ok {
+		return
+	}
+
+	change, err := models.ChangeRole(db, &u, strings.ToLower(input.Role), &actor.ID, models.RoleChangeByAdmin, auditMeta(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": change})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Invite User
+// @Description Create an invitation that grants a role when the email registers with its token, requires user:roles
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body InvitationInput true "the body to invite a user"
+// @Success 200 {object} map[string]any
+// @Router /admin/invitations [post]
+func CreateInvitation(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input InvitationInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	invitationToken, invitation, err := models.CreateInvitation(db, input.Email, strings.ToLower(input.Role), actor.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := map[string]any{
+		"email":            invitation.Email,
+		"role":             invitation.Role,
+		"invitation_token": invitationToken,
+		"expires_at":       invitation.ExpiresAt.Format("02 January 2006 15:04:05"),
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary List Users
+// @Description List users newest first, requires user:manage
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param page query int false "page, starts at 1"
+// @Param limit query int false "users per page, at most 100"
+// @Param role query string false "platform role: user, admin or superadmin"
+// @Param status query string false "active, disabled, locked or unverified"
+// @Param q query string false "search in username and email"
+// @Param created_after query string false "created at or after, 2006-01-02 or RFC 3339"
+// @Param created_before query string false "created before, 2006-01-02 or RFC 3339"
+// @Success 200 {object} map[string]any
+// @Router /admin/users [get]
+func ListUsers(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	p := query.New(c.Request.URL.Query())
+	page := p.OffsetPage(20, 100)
+	filter := models.UserFilter{
+		Role:          p.OneOf("role", "", models.RoleCustomer, models.RoleAdmin, models.RoleSuperAdmin),
+		Status:        p.OneOf("status", "", models.UserStatusActive, models.UserStatusDisabled, models.UserStatusLocked, models.UserStatusUnverified),
+		Search:        p.String("q", ""),
+		CreatedAfter:  p.Time("created_after"),
+		CreatedBefore: p.Time("created_before"),
+	}
+	if respondQueryError(c, p.Err()) {
+		return
+	}
+
+	users, total, err := models.ListUsers(db, filter, page)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+
+	response := []models.AdminUserResponse{}
+	for _, u := range users {
+		response = append(response, models.NewAdminUserResponse(u))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response, "meta": page.Meta(total, "")})
+}
+
+// adminTarget loads the user in the :id param for an admin action. Admins
+// can not act on themselves, and only superadmins can act on superadmins.
+func adminTarget(c *gin.Context, db *gorm.DB) (models.User, models.User, bool) {
+	actor, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return models.User{}, models.User{}, false
+	}
+
+	u := models.User{}
+	if err := db.Model(models.User{}).Where("id = ?", c.Param("id")).Take(&u).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return models.User{}, models.User{}, false
+	}
+
+	if u.ID == actor.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own account here"})
+		return models.User{}, models.User{}, false
+	}
+	if u.Role == models.RoleSuperAdmin && actor.Role != models.RoleSuperAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only a superadmin can change a superadmin"})
+		return models.User{}, models.User{}, false
+	}
+	return actor, u, true
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Get User
+// @Description Get a user with the account state, requires user:manage
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Success 200 {object} models.AdminUserResponse
+// @Router /admin/users/{id} [get]
+func GetUser(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u := models.User{}
+	if err := db.Model(models.User{}).Where("id = ?", c.Param("id")).Take(&u).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.NewAdminUserResponse(u)})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Disable User
+// @Description Block the user from logging in and sign out every session and API key, requires user:manage
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Success 200 {object} models.AdminUserResponse
+// @Router /admin/users/{id}/disable [post]
+func DisableUser(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	_, u, ok := adminTarget(c, db)
+	if !ok {
+		return
+	}
+
+	if !u.Disabled() {
+		if err := u.Disable(db, auditMeta(c)); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable user"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.NewAdminUserResponse(u)})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Enable User
+// @Description Allow a disabled user to login again, requires user:manage
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Success 200 {object} models.AdminUserResponse
+// @Router /admin/users/{id}/enable [post]
+func EnableUser(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	_, u, ok := adminTarget(c, db)
+	if !ok {
+		return
+	}
+
+	if u.Disabled() {
+		if err := u.Enable(db, auditMeta(c)); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable user"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.NewAdminUserResponse(u)})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Force Password Reset
+// @Description Sign the user out everywhere and refuse logins until the password is reset. A reset link is emailed to the user. Requires user:manage
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Success 200 {object} models.AdminUserResponse
+// @Router /admin/users/{id}/force_password_reset [post]
+func ForcePasswordReset(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	mail := c.MustGet("mailer").(mailer.Mailer)
+
+	_, u, ok := adminTarget(c, db)
+	if !ok {
+		return
+	}
+
+	if err := u.RequirePasswordReset(db, auditMeta(c)); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to require a password reset"})
+		return
+	}
+
+	// with too many outstanding links the user can still use one of those
+	if err := sendResetLink(db, mail, u); err != nil {
+		log.Println("send reset link:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.NewAdminUserResponse(u)})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Reset User 2FA
+// @Description Turn two-factor authentication off for a user who lost the authenticator and the recovery codes, requires user:manage
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Success 200 {object} models.AdminUserResponse
+// @Router /admin/users/{id}/reset_mfa [post]
+func ResetUserMFA(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	_, u, ok := adminTarget(c, db)
+	if !ok {
+		return
+	}
+
+	if u.MFAEnabled {
+		if err := u.DisableMFA(db, auditMeta(c)); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset two-factor authentication"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.NewAdminUserResponse(u)})
+}
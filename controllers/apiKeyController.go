@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/models"
+	"final-project/principal"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateAPIKeyInput struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresAt is optional, keys without it stay valid until revoked
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Create API Key
+// @Description Create a named API key for integrations, sent as 'Authorization: ApiKey <key>'. Scopes are permissions such as menu:write, the key can only use the ones its user also holds. The key is only shown once.
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body CreateAPIKeyInput true "the body to create an api key"
+// @Success 200 {object} map[string]any
+// @Router /user/api_keys [post]
+func CreateAPIKey(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input CreateAPIKeyInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if _, ok := principal.APIKey(c); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "api keys can not create api keys, login first"})
+		return
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	key, k, err := models.CreateAPIKey(db, u.ID, input.Name, input.Scopes, input.ExpiresAt)
+	if errors.Is(err, models.ErrAPIKeyInvalidScope) || errors.Is(err, models.ErrAPIKeyNoScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "scopes": models.Permissions})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "store the key safely, it is only shown once", "data": map[string]any{"key": key, "api_key": k}})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary List API Keys
+// @Description List the API keys of the logged in user, including revoked and expired ones.
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} []models.APIKey
+// @Router /user/api_keys [get]
+func ListAPIKeys(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	keys, err := models.ListAPIKeys(db, u.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list api keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": keys})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Revoke API Key
+// @Description Revoke one of the API keys of the logged in user. It stops working immediately.
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "api key id"
+// @Success 200 {object} map[string]any
+// @Router /user/api_keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	err = models.RevokeAPIKey(db, u.ID, uint(id))
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "api key revoked"})
+}
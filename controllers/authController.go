@@ -1,23 +1,21 @@
 package controllers
 
 import (
+	"errors"
 	"final-project/models"
+	"final-project/principal"
 	"final-project/utils"
+	"final-project/utils/mailer"
+	"final-project/utils/password"
+	"final-project/utils/token"
 	"log"
 	"net/http"
-	"strings"
-	"time"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-const (
-	admin = "admin"
-	user  = "user"
-)
-
 type LoginInput struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -25,23 +23,43 @@ type LoginInput struct {
 
 type RegisterInput struct {
 	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
-	Role     string `json:"role"`
+	// InvitationToken applies the role an admin invited this email with
+	InvitationToken string `json:"invitation_token"`
 }
 
 type ResetLinkInput struct {
 	Email string `json:"email" binding:"required"`
 }
 
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type ResetPassInput struct {
-	Password    string `json:"password" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required"`
 }
 
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// respondPasswordPolicy answers 400 with the broken rules under the input
+// field when err is a password policy violation.
+func respondPasswordPolicy(c *gin.Context, field string, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "password does not meet the password policy", "fields": map[string][]string{field: policyErr.Problems}})
+	return true
+}
+
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Login User
-// @Description Logging to get jwt token
+// @Description Logging to get jwt token. Accounts with two-factor authentication get an mfa_token for /login/mfa instead.
 // @Tags Auth
 // @Accept  json
 // @Produce  json
@@ -56,30 +74,202 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	u := models.User{}
 
-	u.Email = input.Email
-	u.Password = input.Password
+	u, err := models.LoginCheck(input.Email, input.Password, db)
 
-	token, username, id, err := models.LoginCheck(u.Email, u.Password, db)
+	if err != nil {
+		recordAudit(db, auditMeta(c), models.AuditEvent{
+			Action:     models.AuditLoginFailed,
+			TargetType: models.AuditTargetUser,
+			Details:    map[string]string{"email_hash": models.AuditEmail(input.Email), "reason": loginFailureReason(err)},
+		})
+	}
 
+	// a locked account gets the same answer as an unknown email, the lock is
+	// only in the audit log
+	if errors.Is(err, models.ErrAccountDisabled) || errors.Is(err, models.ErrPasswordResetRequired) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "username or password incorrect"})
 		return
 	}
 
-	user := map[string]string{
-		"username": username,
-		"email":    u.Email,
+	completeLogin(c, db, u)
+}
+
+// loginFailureReason names a LoginCheck error for the audit log.
+func loginFailureReason(err error) string {
+	var locked *models.AccountLockedError
+	switch {
+	case errors.As(err, &locked):
+		return "locked"
+	case errors.Is(err, models.ErrAccountDisabled):
+		return "disabled"
+	case errors.Is(err, models.ErrPasswordResetRequired):
+		return "password_reset_required"
+	default:
+		return "invalid_credentials"
+	}
+}
+
+// completeLogin answers a checked first factor, with an mfa_token when the
+// account has two-factor authentication and with the tokens otherwise.
+func completeLogin(c *gin.Context, db *gorm.DB, u models.User) {
+	if u.MFAEnabled {
+		challenge, err := token.GenerateActionToken(token.ActionMFALogin, u.ID, "", mfaChallengeLifespan)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor code required", "mfa_required": true, "mfa_token": challenge})
+		return
+	}
+
+	issueLoginTokens(c, db, u)
+}
+
+// issueLoginTokens answers a completed login with a new session, its access
+// token and a refresh token that starts the session's family.
+func issueLoginTokens(c *gin.Context, db *gorm.DB, u models.User) {
+	// every login path ends here, including provider logins and 2FA
+	if err := u.CheckCanLogin(); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := models.CreateSession(db, u.ID, c.Request.UserAgent(), c.ClientIP())
+
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+
+	accessToken, err := token.GenerateToken(u.ID, u.Role, session.ID)
+
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		return
+	}
+
+	refreshToken, _, err := models.CreateRefreshToken(db, u.ID, session.FamilyID)
+
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create refresh token"})
+		return
+	}
+
+	recordAudit(db, auditMetaFor(c, u.ID), models.AuditEvent{
+		Action:     models.AuditLogin,
+		TargetType: models.AuditTargetUser,
+		TargetID:   models.AuditID(u.ID),
+		Details:    map[string]uint{"session_id": session.ID},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Login Success", "user": models.NewUserResponse(u), "token": accessToken, "refresh_token": refreshToken, "id": u.ID})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Refresh Token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Every refresh token can be used once.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param Body body RefreshTokenInput true "the body to refresh a token"
+// @Success 200 {object} map[string]interface{}
+// @Router /token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input RefreshTokenInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refreshToken, rt, err := models.RotateRefreshToken(db, input.RefreshToken)
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	u := models.User{}
+	if err := db.Model(models.User{}).Where("id = ?", rt.UserID).Take(&u).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrRefreshTokenInvalid.Error()})
+		return
+	}
+	if err := u.CheckCanLogin(); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := models.SessionForFamily(db, rt, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrRefreshTokenInvalid.Error()})
+		return
+	}
+
+	accessToken, err := token.GenerateToken(u.ID, u.Role, session.ID)
+
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Refresh Success", "token": accessToken, "refresh_token": refreshToken, "id": rt.UserID})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Logout
+// @Description Revoke the refresh token and every token rotated from the same login. When an access token is sent it is revoked too.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param Body body RefreshTokenInput true "the body to logout"
+// @Success 200 {object} map[string]string
+// @Router /logout [post]
+func Logout(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input RefreshTokenInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rt, err := models.RevokeRefreshFamilyByToken(db, input.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(db, auditMetaFor(c, rt.UserID), models.AuditEvent{
+		Action:     models.AuditLogout,
+		TargetType: models.AuditTargetUser,
+		TargetID:   models.AuditID(rt.UserID),
+	})
+
+	// the access token is optional here, but when it is sent it dies with the session
+	if claims, err := token.ExtractClaims(c); err == nil {
+		if err := token.RevokeClaims(claims); err != nil {
+			log.Println(err)
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Login Success", "user": user, "token": token, "id": id})
+	c.JSON(http.StatusOK, gin.H{"message": "Logout Success"})
 }
 
 // Register godoc
 // @Summary Register a user.
-// @Description registering a user from public access.
+// @Description registering a customer from public access. Other roles need an invitation_token from an admin. A verification link is emailed to the address.
 // @Tags Auth
 // @Param Body body RegisterInput true "the body to register a user"
 // @Produce json
@@ -99,35 +289,47 @@ func Register(c *gin.Context) {
 	u.Username = input.Username
 	u.Email = input.Email
 	u.Password = input.Password
-	u.Role = input.Role
-	if input.Role == "" {
-		u.Role = user
-	} else if strings.ToLower(input.Role) == "admin" {
-		u.Role = admin
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "registration failed", "error": "Role for account only user or admin"})
+	u.Role = models.RoleCustomer
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := u.SaveUser(tx); err != nil {
+			return err
+		}
+		if input.InvitationToken == "" {
+			return nil
+		}
+		invitation, err := models.AcceptInvitation(tx, input.InvitationToken, u.Email)
+		if err != nil {
+			return err
+		}
+		_, err = models.ChangeRole(tx, &u, invitation.Role, &invitation.InvitedByID, models.RoleChangeByInvitation, auditMetaFor(c, u.ID))
+		return err
+	})
+
+	if respondPasswordPolicy(c, "password", err) {
 		return
 	}
-
-	_, err := u.SaveUser(db)
-
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user := map[string]string{
-		"username": input.Username,
-		"email":    input.Email,
-	}
+	recordAudit(db, auditMetaFor(c, u.ID), models.AuditEvent{
+		Action:     models.AuditRegister,
+		TargetType: models.AuditTargetUser,
+		TargetID:   models.AuditID(u.ID),
+		After:      map[string]string{"role": u.Role},
+	})
 
-	c.JSON(http.StatusOK, gin.H{"message": "registration success", "user": user})
+	sendVerificationEmail(db, c.MustGet("mailer").(mailer.Mailer), &u)
+
+	c.JSON(http.StatusOK, gin.H{"message": "registration success, please check your email to verify it", "user": models.NewUserResponse(u)})
 
 }
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Get Reset Link
-// @Description get Link by ID
+// @Description Email a reset password link. The response is the same whether or not the email is registered.
 // @Tags Auth
 // @Accept  json
 // @Produce  json
@@ -136,49 +338,86 @@ func Register(c *gin.Context) {
 // @Router /get_reset_link [post]
 func GetResetLink(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
+	mail := c.MustGet("mailer").(mailer.Mailer)
 	var input ResetLinkInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	response := gin.H{"status": "success", "message": "If the email is registered, a reset link has been sent to it"}
+
 	u := models.User{}
 	err := db.Model(models.User{}).Where("email = ?", input.Email).Take(&u).Error
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+
+	event := models.AuditEvent{Action: models.AuditResetLinkRequest, TargetType: models.AuditTargetUser, Details: map[string]string{"email_hash": models.AuditEmail(input.Email)}}
+	if err == nil {
+		event.TargetID = models.AuditID(u.ID)
 	}
-	email := u.Email
+	recordAudit(db, auditMeta(c), event)
 
-	if email == "" {
-		c.JSON(http.StatusOK, gin.H{"message": "User not found"})
+	if err != nil {
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
-	resetToken := utils.GetToken(10)
-	u.ResetCode = resetToken
-	u.ResetTime = time.Now().Add(time.Minute * 15)
+	// creating the token and sending it in the background keeps the response
+	// time the same for unknown emails
+	go func() {
+		if err := sendResetLink(db, mail, u); err != nil {
+			log.Println("send reset link:", err)
+		}
+	}()
 
-	db.Save(&u)
+	c.JSON(http.StatusOK, response)
+}
 
-	response := map[string]any{
-		"email":        u.Email,
-		"reset_link":   c.Request.Host + "/reset_password/" + u.ResetCode,
-		"link_expired": u.ResetTime.Format("02 January 2006 15:04:05"),
+// resetPasswordLink is the frontend page at RESET_PASSWORD_URL with the token
+// as a query parameter, the page posts it to /reset_password/:token.
+func resetPasswordLink(resetToken string) string {
+	link, err := url.Parse(utils.Getenv("RESET_PASSWORD_URL", "http://localhost:3000/reset_password"))
+	if err != nil {
+		log.Println("RESET_PASSWORD_URL:", err)
+		link = &url.URL{}
 	}
+	q := link.Query()
+	q.Set("token", resetToken)
+	link.RawQuery = q.Encode()
+	return link.String()
+}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response})
+// sendResetLink creates a reset token and emails its link to u in the
+// background.
+func sendResetLink(db *gorm.DB, mail mailer.Mailer, u models.User) error {
+	resetToken, rt, err := models.CreatePasswordResetToken(db, u.ID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(u.Email, "Reset your password", "reset_password", map[string]string{
+		"Username":  emailUsername(u),
+		"Link":      resetPasswordLink(resetToken),
+		"ExpiresAt": rt.ExpiresAt.Format("02 January 2006 15:04:05"),
+	})
+	if err != nil {
+		return err
+	}
+
+	sendInBackground(mail, msg)
+	return nil
 }
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Reset Password
-// @Description reset password
+// @Description Set a new password with the token from the reset link. The token works once and every existing session is signed out.
 // @Tags Auth
 // @Accept  json
 // @Produce  json
+// @Param token path string true "reset token from the emailed link"
 // @Param Body body ResetPassInput true "the body to reset password"
 // @Success 200 {object} map[string]string
-// @Router /reset_password [post]
+// @Router /reset_password/{token} [post]
 func ResetPassword(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var input ResetPassInput
@@ -188,44 +427,90 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	token := c.Param("token")
+	u, err := models.ResetPasswordWithToken(db, c.Param("token"), input.NewPassword)
 
-	u := models.User{}
-	err := db.Model(models.User{}).Where("reset_code = ?", token).Take(&u).Error
-	if err != nil {
+	if respondPasswordPolicy(c, "new_password", err) {
+		return
+	}
+	if errors.Is(err, models.ErrResetTokenInvalid) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
 
-	resetTime := u.ResetTime
+	recordAudit(db, auditMetaFor(c, u.ID), models.AuditEvent{
+		Action:     models.AuditPasswordReset,
+		TargetType: models.AuditTargetUser,
+		TargetID:   models.AuditID(u.ID),
+	})
 
-	if resetTime.Before(time.Now()) {
-		c.JSON(http.StatusOK, gin.H{"message": "link expired, please call reset_link endpoint again"})
-		return
+	response := map[string]any{
+		"message": "Success reset password",
 	}
 
-	password := input.Password
-	new_password := input.NewPassword
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Change Password
+// @Description Change the password of the logged in user. Every existing session, including this one, is signed out.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body ChangePasswordInput true "the body to change password"
+// @Success 200 {object} map[string]string
+// @Router /user/change_password [post]
+func ChangePassword(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input ChangePasswordInput
 
-	errPass := models.VerifyPassword(password, u.Password)
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if errPass != nil && errPass == bcrypt.ErrMismatchedHashAndPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errPass.Error()})
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	hashedPassword, errPassword := bcrypt.GenerateFromPassword([]byte(new_password), bcrypt.DefaultCost)
-	if errPassword != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errPassword.Error()})
+	if err := models.VerifyPassword(input.CurrentPassword, u.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current password incorrect"})
 		return
 	}
-	u.Password = string(hashedPassword)
-	u.ResetCode = ""
-	db.Save(&u)
 
-	response := map[string]any{
-		"message": "Success reset password",
+	if err := u.UpdatePassword(db, input.NewPassword); err != nil {
+		if respondPasswordPolicy(c, "new_password", err) {
+			return
+		}
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response})
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditPasswordChange,
+		TargetType: models.AuditTargetUser,
+		TargetID:   models.AuditID(u.ID),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "password changed, please login again"})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary JSON Web Key Set
+// @Description Public keys that verify access tokens, including keys kept during a rotation
+// @Tags Auth
+// @Produce  json
+// @Success 200 {object} map[string]any
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, token.JWKS())
 }
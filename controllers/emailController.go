@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"final-project/middlewares"
+	"final-project/models"
+	"final-project/principal"
+	"final-project/utils"
+	"final-project/utils/mailer"
+	"final-project/utils/token"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func verifyEmailLifespan() time.Duration {
+	hours, err := strconv.Atoi(utils.Getenv("VERIFY_EMAIL_HOUR_LIFESPAN", "48"))
+	if err != nil || hours <= 0 {
+		hours = 48
+	}
+	return time.Hour * time.Duration(hours)
+}
+
+func verifyResendInterval() time.Duration {
+	seconds, err := strconv.Atoi(utils.Getenv("VERIFY_EMAIL_RESEND_SECONDS", "60"))
+	if err != nil || seconds < 0 {
+		seconds = 60
+	}
+	return time.Second * time.Duration(seconds)
+}
+
+func sendInBackground(mail mailer.Mailer, msg mailer.Message) {
+	go func() {
+		if err := mail.Send(msg); err != nil {
+			log.Printf("send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+// emailUsername undoes the escaping usernames are stored with, the mail
+// templates escape them again.
+func emailUsername(u models.User) string {
+	return html.UnescapeString(u.Username)
+}
+
+// sendVerificationEmail mails a signed link bound to the user's current email,
+// so the link stops working when the email changes. Failures are only logged.
+func sendVerificationEmail(db *gorm.DB, mail mailer.Mailer, u *models.User) {
+	lifespan := verifyEmailLifespan()
+	verifyToken, err := token.GenerateActionToken(token.ActionVerifyEmail, u.ID, u.Email, lifespan)
+	if err != nil {
+		log.Println("create verification token:", err)
+		return
+	}
+
+	msg, err := mailer.Render(u.Email, "Verify your email", "verify_email", map[string]string{
+		"Username":  emailUsername(*u),
+		"Email":     u.Email,
+		"Link":      utils.PublicURL("/verify_email/" + verifyToken),
+		"ExpiresAt": time.Now().Add(lifespan).Format("02 January 2006 15:04:05"),
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err := u.MarkVerificationSent(db); err != nil {
+		log.Println(err)
+	}
+	sendInBackground(mail, msg)
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Verify Email
+// @Description Confirm the email address with the token from the verification link
+// @Tags Auth
+// @Produce  json
+// @Param token path string true "verification token from the emailed link"
+// @Success 200 {object} map[string]string
+// @Router /verify_email/{token} [get]
+func VerifyEmail(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	claims, err := token.ParseActionToken(token.ActionVerifyEmail, c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verification link is invalid or expired"})
+		return
+	}
+
+	u := models.User{}
+	err = db.Model(models.User{}).Where("id = ?", claims.UserID).Take(&u).Error
+	if err != nil || u.Email != claims.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verification link is invalid or expired"})
+		return
+	}
+
+	if !u.EmailVerified() {
+		if err := u.MarkEmailVerified(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "email verified"})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Resend Verification Email
+// @Description Send the verification link again, at most once per VERIFY_EMAIL_RESEND_SECONDS
+// @Tags Auth
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /user/resend_verification [post]
+func ResendVerification(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	mail := c.MustGet("mailer").(mailer.Mailer)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if u.EmailVerified() {
+		c.JSON(http.StatusOK, gin.H{"status": "success", "message": "email already verified"})
+		return
+	}
+
+	if u.VerificationSentAt != nil {
+		wait := time.Until(u.VerificationSentAt.Add(verifyResendInterval()))
+		if wait > 0 {
+			middlewares.TooManyRequests(c, wait, "verification email was sent recently, please wait before asking again")
+			return
+		}
+	}
+
+	sendVerificationEmail(db, mail, &u)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "verification email sent"})
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/middlewares"
+	"final-project/models"
+	"final-project/principal"
+	"final-project/utils/token"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const mfaChallengeLifespan = time.Minute * 5
+
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAEnrollInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+type MFADisableInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// Code is a code from the authenticator app or an unused recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type LoginMFAInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code is a code from the authenticator app or an unused recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Login Second Step
+// @Description Exchange the mfa_token from /login and a TOTP or recovery code for tokens
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param Body body LoginMFAInput true "the body to finish a two-factor login"
+// @Success 200 {object} map[string]interface{}
+// @Router /login/mfa [post]
+func LoginMFA(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input LoginMFAInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := token.ParseActionToken(token.ActionMFALogin, input.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "mfa_token is invalid or expired, please login again"})
+		return
+	}
+
+	u := models.User{}
+	if err := db.Model(models.User{}).Where("id = ?", claims.UserID).Take(&u).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "mfa_token is invalid or expired, please login again"})
+		return
+	}
+
+	if err := u.CheckLocked(); err != nil {
+		middlewares.TooManyRequests(c, time.Until(*u.LockedUntil), err.Error())
+		return
+	}
+
+	if err := u.VerifyMFA(db, input.Code); err != nil {
+		if !errors.Is(err, models.ErrMFAInvalidCode) {
+			log.Println(err)
+		}
+		if err := u.RegisterLoginFailure(db); err != nil {
+			log.Println(err)
+		}
+		recordAudit(db, auditMetaFor(c, u.ID), models.AuditEvent{
+			Action:     models.AuditLoginFailed,
+			TargetType: models.AuditTargetUser,
+			TargetID:   models.AuditID(u.ID),
+			Details:    map[string]string{"reason": "invalid_mfa_code"},
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrMFAInvalidCode.Error()})
+		return
+	}
+
+	if err := u.ResetLoginFailures(db); err != nil {
+		log.Println(err)
+	}
+
+	issueLoginTokens(c, db, u)
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Start 2FA Enrollment
+// @Description Create a TOTP secret and return its otpauth URI. It is activated by /user/mfa/verify.
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body MFAEnrollInput true "the body to start enrollment"
+// @Success 200 {object} map[string]string
+// @Router /user/mfa/enroll [post]
+func EnrollMFA(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input MFAEnrollInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := models.VerifyPassword(input.CurrentPassword, u.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current password incorrect"})
+		return
+	}
+
+	secret, uri, err := u.StartMFAEnrollment(db)
+	if errors.Is(err, models.ErrMFAAlreadyEnabled) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": map[string]string{"secret": secret, "otpauth_uri": uri}})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Confirm 2FA Enrollment
+// @Description Enable two-factor authentication with the first code from the app. The recovery codes are only shown once.
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body MFACodeInput true "the body to confirm enrollment"
+// @Success 200 {object} map[string]any
+// @Router /user/mfa/verify [post]
+func VerifyMFAEnrollment(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input MFACodeInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	codes, err := u.ConfirmMFAEnrollment(db, input.Code)
+	if errors.Is(err, models.ErrMFAAlreadyEnabled) || errors.Is(err, models.ErrMFANotPending) || errors.Is(err, models.ErrMFAInvalidCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "two-factor authentication enabled, store the recovery codes safely", "data": map[string]any{"recovery_codes": codes}})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Disable 2FA
+// @Description Turn two-factor authentication off with the password and a TOTP or recovery code. The recovery codes are deleted.
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body MFADisableInput true "the body to disable two-factor authentication"
+// @Success 200 {object} map[string]string
+// @Router /user/mfa/disable [post]
+func DisableMFA(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input MFADisableInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if !u.MFAEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrMFANotEnabled.Error()})
+		return
+	}
+	if err := models.VerifyPassword(input.CurrentPassword, u.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current password incorrect"})
+		return
+	}
+	if err := u.VerifyMFA(db, input.Code); err != nil {
+		if !errors.Is(err, models.ErrMFAInvalidCode) {
+			log.Println(err)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrMFAInvalidCode.Error()})
+		return
+	}
+
+	if err := u.DisableMFA(db, auditMeta(c)); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "two-factor authentication disabled"})
+}
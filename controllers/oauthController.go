@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"final-project/middlewares"
+	"final-project/models"
+	"final-project/utils/oidc"
+	"final-project/utils/token"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func oidcProvider(c *gin.Context) (*oidc.Provider, bool) {
+	providers := c.MustGet("oidc").(map[string]*oidc.Provider)
+	p, ok := providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": oidc.ErrUnknownProvider.Error()})
+		return nil, false
+	}
+	return p, true
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Login with a provider
+// @Description Redirect the browser to the OpenID Connect provider. The provider sends it back to /oauth/{provider}/callback.
+// @Tags Auth
+// @Param provider path string true "provider name from OIDC_PROVIDERS"
+// @Success 302
+// @Router /oauth/{provider}/login [get]
+func OAuthLogin(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	p, ok := oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	nonce, err := token.GenerateOpaqueToken(16)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	state, err := models.CreateOIDCLoginState(db, p.Name, verifier, nonce)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	redirect, err := p.AuthCodeURL(c.Request.Context(), state, nonce, challenge)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "login provider is unavailable"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Provider callback
+// @Description Complete a provider login. The account linked to the identity is logged in, an account with the same email, verified by the provider and by this account, is linked, otherwise a customer account is created. Answers like /login.
+// @Tags Auth
+// @Produce  json
+// @Param provider path string true "provider name from OIDC_PROVIDERS"
+// @Param code query string true "authorization code"
+// @Param state query string true "state sent to the provider"
+// @Success 200 {object} map[string]interface{}
+// @Router /oauth/{provider}/callback [get]
+func OAuthCallback(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	p, ok := oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	if e := c.Query("error"); e != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login was not completed at the provider: " + e})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	s, err := models.ConsumeOIDCLoginState(db, p.Name, state)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identity, err := p.Exchange(c.Request.Context(), code, s.Verifier, s.Nonce)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login with the provider failed"})
+		return
+	}
+
+	u, err := models.UserForIdentity(db, p.Name, identity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := u.CheckLocked(); err != nil {
+		middlewares.TooManyRequests(c, time.Until(*u.LockedUntil), err.Error())
+		return
+	}
+
+	completeLogin(c, db, u)
+}
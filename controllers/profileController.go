@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/models"
+	"final-project/principal"
+	"final-project/utils/mailer"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UpdateProfileInput struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	// CurrentPassword is required to change the email
+	CurrentPassword string `json:"current_password"`
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary My Profile
+// @Description Get the account of the logged in user, requires account:read
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} models.UserResponse
+// @Router /user/me [get]
+func GetProfile(c *gin.Context) {
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.NewUserResponse(u)})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Update My Profile
+// @Description Change the username or email of the logged in user. Changing the email needs current_password and a new verification of the address.
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param Body body UpdateProfileInput true "the fields to change"
+// @Success 200 {object} models.UserResponse
+// @Router /user/me [patch]
+func UpdateProfile(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	mail := c.MustGet("mailer").(mailer.Mailer)
+	var input UpdateProfileInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if input.Email != nil && *input.Email != u.Email {
+		if err := models.VerifyPassword(input.CurrentPassword, u.Password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "current password incorrect"})
+			return
+		}
+	}
+
+	emailChanged, err := u.UpdateProfile(db, input.Username, input.Email)
+	if errors.Is(err, models.ErrUsernameEmpty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrUsernameTaken) || errors.Is(err, models.ErrEmailTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+		return
+	}
+
+	message := "profile updated"
+	if emailChanged {
+		sendVerificationEmail(db, mail, &u)
+		message = "profile updated, please verify the new email"
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": message, "data": models.NewUserResponse(u)})
+}
@@ -1,23 +1,61 @@
 package controllers
 
 import (
+	"errors"
 	"final-project/models"
+	"final-project/principal"
+	"final-project/utils/query"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type RestaurantInput struct {
-	Name string `json:"name"`
-	City string `json:"city"`
+	Name             string         `json:"name"`
+	City             string         `json:"city"`
+	Cuisine          string         `json:"cuisine"`
+	Address          models.Address `json:"address"`
+	Latitude         *float64       `json:"latitude"`
+	Longitude        *float64       `json:"longitude"`
+	DeliveryRadiusKm float64        `json:"delivery_radius_km"`
+	// OwnerID hands the restaurant to another user, defaults to the caller
+	OwnerID uint `json:"owner_id"`
+}
+
+type UpdateRestaurantInput struct {
+	Name             *string         `json:"name"`
+	City             *string         `json:"city"`
+	Cuisine          *string         `json:"cuisine"`
+	Address          *models.Address `json:"address"`
+	Latitude         *float64        `json:"latitude"`
+	Longitude        *float64        `json:"longitude"`
+	DeliveryRadiusKm *float64        `json:"delivery_radius_km"`
+}
+
+type OpeningHourInput struct {
+	// Weekday is 0 for sunday to 6 for saturday
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens" binding:"required"`
+	Closes  string `json:"closes" binding:"required"`
+}
+
+type OpeningHoursInput struct {
+	Hours []OpeningHourInput `json:"hours" binding:"dive"`
+}
+
+type RestaurantMemberInput struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required"`
 }
 
 type MenuParams struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Price       string `json:"price"`
+	Category    string `json:"category"`
 }
 
 type MenuRestaurantInput struct {
@@ -25,51 +63,378 @@ type MenuRestaurantInput struct {
 	Menus []MenuParams `json:"menus"`
 }
 
-type APIRestaurant struct {
-	ID   uint
-	Name string
-	City string
+// respondQueryError answers 400 with the problems of each query parameter
+// when err comes from the query package.
+func respondQueryError(c *gin.Context, err error) bool {
+	var queryErr *query.Error
+	if !errors.As(err, &queryErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters", "fields": queryErr.Problems})
+	return true
 }
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Get All Restaurant
-// @Description Get data for all resto
+// @Description List open restaurants with their rating. Pages are selected with page and limit, or with the next_cursor of the previous page as cursor; meta holds the total count.
 // @Tags restaurant
 // @Accept  json
 // @Produce  json
+// @Param page query int false "page, starts at 1"
+// @Param limit query int false "restaurants per page, at most 100"
+// @Param cursor query string false "next_cursor of the previous page, keeps its sort"
+// @Param city query string false "city"
+// @Param cuisine query string false "cuisine"
+// @Param min_rating query number false "minimum average rating"
+// @Param open_now query bool false "only restaurants open now"
+// @Param sort query string false "rating_avg (default), total_review, name or newest"
+// @Param order query string false "asc or desc, defaults to asc for name and desc otherwise"
 // @Success 200 {object} map[string]any
 // @Router /get_all_resto [get]
+// @Router /restaurants [get]
 func GetAllRestaurant(c *gin.Context) {
 	// get db from gin context
 	db := c.MustGet("db").(*gorm.DB)
-	var restaurant []APIRestaurant
-	db.Model(&models.Restaurant{}).Find(&restaurant)
 
-	response := []map[string]any{}
+	p := query.New(c.Request.URL.Query())
+	page := p.Page(20, 100)
+	filter := models.RestaurantFilter{
+		City:      p.String("city", ""),
+		Cuisine:   strings.ToLower(p.String("cuisine", "")),
+		MinRating: p.Float("min_rating"),
+	}
+	if p.Bool("open_now") {
+		now := models.RestaurantTime()
+		filter.OpenAt = &now
+	}
+	sort := models.RestaurantSort{
+		Key:   p.OneOf("sort", models.RestaurantSortKeys[0], models.RestaurantSortKeys...),
+		Order: p.OneOf("order", "", "asc", "desc"),
+	}
+	if respondQueryError(c, p.Err()) {
+		return
+	}
+
+	restaurants, total, next, err := models.ListRestaurants(db, filter, sort, page)
+	if respondQueryError(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": restaurants, "meta": page.Meta(total, next)})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Nearby Restaurants
+// @Description List the open restaurants within radius km of a point, nearest first, with their distance_km. Restaurants without a location are left out.
+// @Tags restaurant
+// @Produce  json
+// @Param lat query number true "latitude of the point"
+// @Param lng query number true "longitude of the point"
+// @Param radius query number false "search radius in km, 5 by default and at most 50"
+// @Param delivers query bool false "only restaurants whose delivery radius reaches the point"
+// @Param page query int false "page, starts at 1"
+// @Param limit query int false "restaurants per page, at most 100"
+// @Success 200 {object} map[string]any
+// @Router /restaurants/nearby [get]
+func NearbyRestaurants(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	p := query.New(c.Request.URL.Query())
+	p.Require("lat", "lng")
+	filter := models.NearbyFilter{
+		Latitude:  p.FloatRange("lat", 0, -90, 90),
+		Longitude: p.FloatRange("lng", 0, -180, 180),
+		RadiusKm:  p.FloatRange("radius", 5, 0.1, 50),
+		Delivers:  p.Bool("delivers"),
+	}
+	page := p.OffsetPage(20, 100)
+	if respondQueryError(c, p.Err()) {
+		return
+	}
+
+	restaurants, total, err := models.ListNearbyRestaurants(db, filter, page)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": restaurants, "meta": page.Meta(total, "")})
+}
+
+// restaurantFields are the fields GetRestaurant can select, restaurantIncludes
+// what it can embed. Both default to everything.
+var (
+	restaurantFields   = []string{"id", "name", "city", "cuisine", "address", "latitude", "longitude", "delivery_radius_km", "user_id", "rating_avg", "total_review", "rating", "open_now", "created_at", "updated_at"}
+	restaurantIncludes = []string{"menus", "reviews", "opening_hours"}
+)
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Get Restaurant
+// @Description Get a restaurant by id with its rating summary, menus grouped by category, recent reviews and opening hours. Closed restaurants are not found.
+// @Tags restaurant
+// @Produce  json
+// @Param id path string true "restaurant id"
+// @Param fields query string false "comma separated fields to return: id, name, city, cuisine, address, latitude, longitude, delivery_radius_km, user_id, rating_avg, total_review, rating, open_now, created_at, updated_at"
+// @Param include query string false "comma separated embeds: menus, reviews, opening_hours, empty for none"
+// @Param reviews_limit query int false "recent reviews to embed, 5 by default and at most 50"
+// @Success 200 {object} map[string]any
+// @Router /restaurants/{id} [get]
+func GetRestaurant(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	p := query.New(c.Request.URL.Query())
+	fields := map[string]bool{}
+	for _, f := range p.Subset("fields", restaurantFields, restaurantFields...) {
+		fields[f] = true
+	}
+	include := map[string]bool{}
+	for _, i := range p.Subset("include", restaurantIncludes, restaurantIncludes...) {
+		include[i] = true
+	}
+	reviewsLimit := p.Int("reviews_limit", 5, 1, 50)
+	if respondQueryError(c, p.Err()) {
+		return
+	}
+
+	restaurant, err := models.SearchRestaurant(c.Param("id"), db)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+		return
+	}
+
+	attributes := map[string]any{"id": restaurant.ID, "name": restaurant.Name, "city": restaurant.City, "cuisine": restaurant.Cuisine, "address": restaurant.Address, "latitude": restaurant.Latitude, "longitude": restaurant.Longitude, "delivery_radius_km": restaurant.DeliveryRadiusKm, "user_id": restaurant.UserID, "total_review": restaurant.TotalReview, "rating_avg": restaurant.RatingAvg, "created_at": restaurant.CreatedAt, "updated_at": restaurant.UpdatedAt}
+	data := map[string]any{}
+	for f := range fields {
+		if v, ok := attributes[f]; ok {
+			data[f] = v
+		}
+	}
+
+	if fields["rating"] {
+		summary, err := models.GetRatingSummary(db, restaurant)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data["rating"] = summary
+	}
+
+	if fields["open_now"] || include["opening_hours"] {
+		hours, err := models.GetOpeningHours(db, restaurant.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if fields["open_now"] {
+			data["open_now"] = models.IsOpenAt(hours, models.RestaurantTime())
+		}
+		if include["opening_hours"] {
+			data["opening_hours"] = models.FormatOpeningHours(hours)
+		}
+	}
+
+	if include["menus"] {
+		menus, err := models.GetMenuGroups(db, restaurant.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data["menus"] = menus
+	}
+
+	if include["reviews"] {
+		reviews, err := models.RecentReviews(db, restaurant.ID, reviewsLimit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data["reviews"] = reviews
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": data})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Update Restaurant
+// @Description Change the name, cuisine, address, location or delivery radius of a restaurant, requires restaurant:write on the restaurant. Latitude and longitude are set together.
+// @Tags restaurant
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "restaurant id"
+// @Param Body body UpdateRestaurantInput true "the fields to change"
+// @Success 200 {object} map[string]models.Restaurant
+// @Router /restaurants/{id} [patch]
+func UpdateRestaurant(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input UpdateRestaurantInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	restaurant, err := models.SearchRestaurant(c.Param("id"), db)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+		return
+	}
+
+	if input.Name != nil && strings.TrimSpace(*input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name can not be empty"})
+		return
+	}
+
+	before := restaurant
+	changes := models.RestaurantChanges{
+		Name:             input.Name,
+		City:             input.City,
+		Cuisine:          input.Cuisine,
+		Address:          input.Address,
+		Latitude:         input.Latitude,
+		Longitude:        input.Longitude,
+		DeliveryRadiusKm: input.DeliveryRadiusKm,
+	}
+	if err := restaurant.UpdateRestaurant(db, changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditRestaurantUpdate,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   models.AuditID(restaurant.ID),
+		Before:     restaurantAuditFields(before),
+		After:      restaurantAuditFields(restaurant),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": restaurant})
+}
+
+// restaurantAuditFields are the fields UpdateRestaurant can change.
+func restaurantAuditFields(r models.Restaurant) map[string]any {
+	return map[string]any{
+		"name":               r.Name,
+		"city":               r.City,
+		"cuisine":            r.Cuisine,
+		"address":            r.Address,
+		"latitude":           r.Latitude,
+		"longitude":          r.Longitude,
+		"delivery_radius_km": r.DeliveryRadiusKm,
+	}
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Close Restaurant
+// @Description Close a restaurant, requires restaurant:write on the restaurant. It disappears from listings and takes no new orders or reviews, past orders and reviews are kept.
+// @Tags restaurant
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "restaurant id"
+// @Success 200 {object} map[string]string
+// @Router /restaurants/{id} [delete]
+func DeleteRestaurant(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	restaurant, err := models.SearchRestaurant(c.Param("id"), db)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+		return
+	}
+
+	if err := restaurant.CloseRestaurant(db); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditRestaurantClose,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   models.AuditID(restaurant.ID),
+		Before:     restaurant,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "restaurant closed"})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Set Opening Hours
+// @Description Replace the opening hours of a restaurant, requires restaurant:write on the restaurant. Times are HH:MM in RESTAURANT_TIMEZONE, a period closing before it opens ends the next day.
+// @Tags restaurant
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "restaurant id"
+// @Param Body body OpeningHoursInput true "the opening hours"
+// @Success 200 {object} map[string]any
+// @Router /restaurant/{id}/opening_hours [put]
+func SetOpeningHours(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input OpeningHoursInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	for _, v := range restaurant {
-		ratingAvg, totalReview, err := models.SearchReviewByResto(v.ID, db)
+	restaurant, err := models.SearchRestaurant(c.Param("id"), db)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+		return
+	}
+
+	hours := []models.OpeningHour{}
+	for _, v := range input.Hours {
+		opens, err := models.ParseClock(v.Opens)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		closes, err := models.ParseClock(v.Closes)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		data := map[string]any{"id": v.ID, "name": v.Name, "city": v.City, "total_review": totalReview, "rating_avg": ratingAvg}
+		hours = append(hours, models.OpeningHour{Weekday: v.Weekday, OpensAt: opens, ClosesAt: closes})
+	}
+
+	before, err := models.GetOpeningHours(db, restaurant.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		response = append(response, data)
+	if err := models.SetOpeningHours(db, restaurant.ID, hours); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response})
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditRestaurantUpdate,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   models.AuditID(restaurant.ID),
+		Before:     map[string]any{"opening_hours": models.FormatOpeningHours(before)},
+		After:      map[string]any{"opening_hours": models.FormatOpeningHours(hours)},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": models.FormatOpeningHours(hours)})
 }
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Create data Restaurant
-// @Description Create Restaurant
+// @Description Create Restaurant, the owner (the caller unless owner_id is set) is granted the owner role on it
 // @Tags restaurant
 // @Accept  json
 // @Produce  json
 // @Param Body body RestaurantInput true "the body to create a restaurant"
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Param HTTP-X-UID header string true "HTTP-X-UID. Fill with id user"
 // @Security BearerToken
 // @Success 200 {object} map[string]models.Restaurant
 // @Router /restaurant/create [post]
@@ -85,14 +450,29 @@ func CreateRestaurant(c *gin.Context) {
 
 	u.Name = input.Name
 	u.City = input.City
-	common_req := c.Value("common_request").(models.CommonRequest)
-
-	if !common_req.IsAdmin {
-		c.JSON(http.StatusOK, gin.H{"message": "Cannot add restaurant. Please contact admin or SuperAdmin"})
+	u.Cuisine = strings.ToLower(strings.TrimSpace(input.Cuisine))
+	if err := models.ValidateLocation(input.Latitude, input.Longitude, input.DeliveryRadiusKm); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	u.Address = input.Address
+	u.Latitude = input.Latitude
+	u.Longitude = input.Longitude
+	u.DeliveryRadiusKm = input.DeliveryRadiusKm
+	owner, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if input.OwnerID != 0 && input.OwnerID != owner.ID {
+		if err := db.Model(models.User{}).Where("id = ?", input.OwnerID).Take(&owner).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "owner not found"})
+			return
+		}
+	}
 
-	u.User = common_req.User
+	u.User = owner
 
 	_, err := u.SaveRestaurant(db)
 
@@ -101,9 +481,17 @@ func CreateRestaurant(c *gin.Context) {
 		return
 	}
 
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditRestaurantCreate,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   models.AuditID(u.ID),
+		After:      u,
+	})
+
 	restaurant := map[string]string{
-		"name": input.Name,
-		"city": input.City,
+		"name":    input.Name,
+		"city":    input.City,
+		"cuisine": u.Cuisine,
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "success create restaurant", "data": restaurant})
@@ -111,13 +499,12 @@ func CreateRestaurant(c *gin.Context) {
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Create Menus
-// @Description Add menu by id Restaurant
+// @Description Add menu by id Restaurant, requires menu:write on the restaurant
 // @Tags Menus
 // @Accept  json
 // @Produce  json
 // @Param Body body MenuRestaurantInput true "the body to create a menu restaurant"
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Param HTTP-X-UID header string true "HTTP-X-UID. Fill with id user"
 // @Security BearerToken
 // @Success 200 {object} map[string]string
 // @Router /create/menus [post]
@@ -125,13 +512,6 @@ func CreateMenus(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var input MenuRestaurantInput
 
-	common_req := c.Value("common_request").(models.CommonRequest)
-
-	if !common_req.IsAdmin {
-		c.JSON(http.StatusOK, gin.H{"message": "Cannot add menu restaurant. Please contact admin or SuperAdmin"})
-		return
-	}
-
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -147,7 +527,7 @@ func CreateMenus(c *gin.Context) {
 
 	for _, v := range input.Menus {
 		price, _ := strconv.Atoi(v.Price)
-		var data = models.Menu{Name: v.Name, Description: v.Description, Price: price, Restaurant: restaurant, RestaurantID: restaurant.ID}
+		var data = models.Menu{Name: v.Name, Description: v.Description, Price: price, Category: strings.TrimSpace(v.Category), Restaurant: restaurant, RestaurantID: restaurant.ID}
 		u = append(u, data)
 	}
 
@@ -158,17 +538,23 @@ func CreateMenus(c *gin.Context) {
 		return
 	}
 
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditMenuCreate,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   models.AuditID(restaurant.ID),
+		After:      u,
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": `success create ` + strconv.Itoa(rows) + ` Menu restaurant`})
 }
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Delete Menus
-// @Description Delete menus by restaurant id
+// @Description Delete menus by restaurant id, requires menu:write on the restaurant
 // @Tags menus
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Param HTTP-X-UID header string true "HTTP-X-UID. Fill with id user"
 // @Security BearerToken
 // @Param id path string true "restaurant id"
 // @Success 200 {object} map[string]string
@@ -189,7 +575,102 @@ func DeleteMenus(c *gin.Context) {
 		return
 	}
 
-	db.Delete(&menus)
+	if err := db.Delete(&menus).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditMenuDelete,
+		TargetType: models.AuditTargetMenu,
+		TargetID:   models.AuditID(menus.ID),
+		Before:     menus,
+	})
 
 	c.JSON(http.StatusOK, gin.H{"data": "Success delete Menus "})
 }
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Add Restaurant Member
+// @Description Grant a user the owner or staff role on a restaurant, requires restaurant:members on the restaurant
+// @Tags restaurant
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "restaurant id"
+// @Param Body body RestaurantMemberInput true "the body to grant a restaurant role"
+// @Success 200 {object} map[string]models.RestaurantMember
+// @Router /restaurant/{id}/members [post]
+func AddRestaurantMember(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var input RestaurantMemberInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	restaurant, err := models.SearchRestaurant(c.Param("id"), db)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Restaurant not found"})
+		return
+	}
+
+	u := models.User{}
+	if err := db.Model(models.User{}).Where("id = ?", input.UserID).Take(&u).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+
+	before := models.RestaurantMember{}
+	db.Where("restaurant_id = ? AND user_id = ?", restaurant.ID, u.ID).Take(&before)
+
+	member, err := models.SetRestaurantMember(db, restaurant.ID, u.ID, strings.ToLower(input.Role))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event := models.AuditEvent{
+		Action:     models.AuditRestaurantMember,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   models.AuditID(restaurant.ID),
+		After:      map[string]any{"user_id": u.ID, "role": member.Role},
+	}
+	if before.ID != 0 {
+		event.Before = map[string]any{"user_id": u.ID, "role": before.Role}
+	}
+	recordAudit(db, auditMeta(c), event)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": member})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Remove Restaurant Member
+// @Description Revoke a user's role on a restaurant, requires restaurant:members on the restaurant
+// @Tags restaurant
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "restaurant id"
+// @Param user_id path string true "user id"
+// @Success 200 {object} map[string]string
+// @Router /restaurant/{id}/members/{user_id} [delete]
+func RemoveRestaurantMember(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	if err := models.RemoveRestaurantMember(db, c.Param("id"), c.Param("user_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+		return
+	}
+
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditRestaurantRemoved,
+		TargetType: models.AuditTargetRestaurant,
+		TargetID:   c.Param("id"),
+		Before:     map[string]string{"user_id": c.Param("user_id")},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "member removed"})
+}
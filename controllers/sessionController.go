@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/models"
+	"final-project/principal"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SessionResponse struct {
+	models.Session
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
+
+// currentSessionID is the session of the request token, 0 for API keys and
+// tokens from before sessions existed.
+func currentSessionID(c *gin.Context) uint {
+	claims, ok := principal.Claims(c)
+	if !ok {
+		return 0
+	}
+	return claims.SessionID
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary List Sessions
+// @Description List the active logins of the logged in user with user agent, IP and when they were last seen
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} []SessionResponse
+// @Router /user/sessions [get]
+func ListSessions(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	sessions, err := models.ListSessions(db, u.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+
+	current := currentSessionID(c)
+	response := []SessionResponse{}
+	for _, s := range sessions {
+		response = append(response, SessionResponse{Session: s, Current: s.ID == current})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": response})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Revoke Session
+// @Description Sign out one session of the logged in user. Its access and refresh tokens stop working immediately.
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Param id path string true "session id"
+// @Success 200 {object} map[string]string
+// @Router /user/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	err = models.RevokeSession(db, u.ID, uint(id))
+	if errors.Is(err, models.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "session revoked"})
+}
+
+// ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
+// @Summary Revoke Other Sessions
+// @Description Sign out every session of the logged in user except the one making the request
+// @Tags User
+// @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
+// @Success 200 {object} map[string]any
+// @Router /user/sessions [delete]
+func RevokeOtherSessions(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	u, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	n, err := models.RevokeOtherSessions(db, u.ID, currentSessionID(c))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "other sessions revoked", "data": gin.H{"revoked": n}})
+}
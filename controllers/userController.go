@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"final-project/models"
+	"final-project/principal"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -37,7 +38,6 @@ type ReviewUserInput struct {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Param HTTP-X-UID header string true "HTTP-X-UID. Fill with id user"
 // @Security BearerToken
 // @Param Body body OrderMenuInput true "for create order"
 // @Success 200 {object} map[string]models.OrderHistory
@@ -46,7 +46,11 @@ func CreateOrder(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var input OrderMenuInput
 
-	user := c.Value("common_request").(models.CommonRequest).User
+	user, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -88,15 +92,28 @@ func CreateOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	menu_ids := []uint{}
+	for _, menu := range orderMenus.Menus {
+		menu_ids = append(menu_ids, menu.ID)
+	}
+	recordAudit(db, auditMeta(c), models.AuditEvent{
+		Action:     models.AuditOrderCreate,
+		TargetType: models.AuditTargetOrder,
+		TargetID:   models.AuditID(orderMenus.ID),
+		After:      map[string]any{"restaurant_id": orderMenus.RestaurantID, "total_price": orderMenus.TotalPrice, "menu_ids": menu_ids},
+	})
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": orderMenus})
 }
 
 // ref: https://swaggo.github.io/swaggo.io/declarative_comments_format/api_operation.html
 // @Summary Show Order By Resto
-// @Description Get Order by id
+// @Description Get Orders of a restaurant, requires order:read on the restaurant
 // @Tags Order User
 // @Accept  json
 // @Produce  json
+// @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
+// @Security BearerToken
 // @Param id path string true "Restaurant id"
 // @Success 200 {object} models.OrderHistory
 // @Router /show/order/:id/restaurant [get]
@@ -128,7 +145,6 @@ func ShowOrderByResto(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Authorization. How to input in swagger : 'Bearer <insert_your_token_here>'"
-// @Param HTTP-X-UID header string true "HTTP-X-UID. Fill with id user"
 // @Security BearerToken
 // @Param body body ReviewUserInput true "the body to create a review"
 // @Success 200 {object} map[string]any
@@ -137,7 +153,11 @@ func CreateReview(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var input ReviewUserInput
 
-	user := c.Value("common_request").(models.CommonRequest).User
+	user, ok := principal.User(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -158,7 +178,7 @@ func CreateReview(c *gin.Context) {
 
 	_, errCreate := review.CreateReview(db)
 	if errCreate != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCreate.Error()})
 		return
 	}
 
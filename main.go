@@ -5,7 +5,10 @@ import (
 	"final-project/docs"
 	"final-project/routes"
 	"final-project/utils"
+	"final-project/utils/mailer"
+	"final-project/utils/token"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
 )
@@ -30,6 +33,14 @@ func main() {
 			log.Fatal("Error loading .env file")
 		}
 	}
+
+	if err := token.Init(); err != nil {
+		log.Fatal("Error loading jwt keys: ", err)
+	}
+	if environment == "production" && token.UsesDefaultSecret() {
+		log.Fatal("Refusing to start in production with the default API_SECRET, configure API_SECRET or JWT_SIGNING_KEY_FILE")
+	}
+
 	// programmatically set swagger info
 	docs.SwaggerInfo.Title = "Swagger Example API"
 	docs.SwaggerInfo.Description = "This is a sample server Restaurant."
@@ -42,7 +53,30 @@ func main() {
 	sqlDB, _ := db.DB()
 	defer sqlDB.Close()
 
+	// go run . create-superadmin -username root -email root@example.com -password secret
+	if len(os.Args) > 1 && os.Args[1] == "create-superadmin" {
+		if err := createSuperAdmin(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// go run . purge-deleted-accounts
+	if len(os.Args) > 1 && os.Args[1] == "purge-deleted-accounts" {
+		if err := purgeDeletedAccounts(db); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("Error configuring mailer: ", err)
+	}
+
+	startAccountPurger(db)
+
 	// router
-	r := routes.SetupRouter(db)
+	r := routes.SetupRouter(db, mail)
 	r.Run(":8080")
 }
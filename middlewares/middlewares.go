@@ -2,35 +2,94 @@ package middlewares
 
 import (
 	"final-project/models"
+	"final-project/principal"
 	"final-project/utils/token"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// deprecatedUIDHeader used to carry the user id alongside the token. It is no
+// longer trusted, but clients that still send it must send their own id.
+const deprecatedUIDHeader = "HTTP-X-UID"
+
+// apiKeyScheme is the Authorization scheme for API keys, "ApiKey <key>".
+const apiKeyScheme = "ApiKey "
+
+// JwtAuthMiddleware authenticates the request with a JWT, or with an API key
+// sent as "Authorization: ApiKey <key>", and stores the principal.
 func JwtAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		err := token.TokenValid(c)
-		user_id := c.Request.Header.Get("HTTP-X-UID")
-		if err != nil || user_id == "" {
+		if header := c.Request.Header.Get("Authorization"); strings.HasPrefix(header, apiKeyScheme) {
+			apiKeyAuth(c, db, strings.TrimSpace(strings.TrimPrefix(header, apiKeyScheme)))
+			return
+		}
+
+		claims, err := token.ExtractClaims(c)
+		if err != nil {
 			c.String(http.StatusUnauthorized, err.Error())
 			c.Abort()
 			return
 		}
+		header_id := c.Request.Header.Get(deprecatedUIDHeader)
+		if header_id != "" && header_id != claims.Subject {
+			c.String(http.StatusUnauthorized, deprecatedUIDHeader+" does not match token")
+			c.Abort()
+			return
+		}
 		u := models.User{}
 		common_req := models.CommonRequest{}
-		err = db.Model(u).Where("id = ?", user_id).Take(&u).Error
+		err = db.Model(u).Where("id = ?", claims.UserID).Take(&u).Error
 		if err != nil {
 			c.String(http.StatusUnauthorized, err.Error())
 			c.Abort()
 			return
 		}
-		if u.Role == "admin" {
-			common_req.IsAdmin = true
+		if u.TokenRevoked(claims.IssuedAtTime()) {
+			c.String(http.StatusUnauthorized, token.ErrTokenRevoked.Error())
+			c.Abort()
+			return
+		}
+		if u.Disabled() {
+			c.String(http.StatusUnauthorized, models.ErrAccountDisabled.Error())
+			c.Abort()
+			return
+		}
+		// tokens from before sessions existed carry no session id
+		if claims.SessionID != 0 {
+			if _, err := models.ActiveSession(db, claims.SessionID, u.ID, c.ClientIP()); err != nil {
+				c.String(http.StatusUnauthorized, err.Error())
+				c.Abort()
+				return
+			}
 		}
+		common_req.IsAdmin = models.IsPlatformAdmin(u.Role)
 		common_req.User = u
-		c.Set("common_request", common_req)
+		common_req.Claims = claims
+		principal.Set(c, common_req)
 		c.Next()
 	}
 }
+
+func apiKeyAuth(c *gin.Context, db *gorm.DB, key string) {
+	k, u, err := models.AuthenticateAPIKey(db, key)
+	if err != nil {
+		c.String(http.StatusUnauthorized, err.Error())
+		c.Abort()
+		return
+	}
+	// keys stop working while the owner has to reset the password
+	if err := u.CheckCanLogin(); err != nil {
+		c.String(http.StatusUnauthorized, err.Error())
+		c.Abort()
+		return
+	}
+	common_req := models.CommonRequest{}
+	common_req.IsAdmin = models.IsPlatformAdmin(u.Role)
+	common_req.User = u
+	common_req.APIKey = &k
+	principal.Set(c, common_req)
+	c.Next()
+}
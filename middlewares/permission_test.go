@@ -0,0 +1,134 @@
+package middlewares
+
+import (
+	"final-project/models"
+	"final-project/principal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newPermissionTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.User{}, &models.Restaurant{}, &models.RestaurantMember{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// checkAccess serves GET /restaurants/:id for caller behind the handlers and
+// returns the status. A nil caller is an unauthenticated request.
+func checkAccess(t *testing.T, db *gorm.DB, caller *models.CommonRequest, restaurant_id string, handlers ...gin.HandlerFunc) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("db", db)
+		if caller != nil {
+			principal.Set(c, *caller)
+		}
+	})
+	handlers = append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/restaurants/:id", handlers...)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/restaurants/"+restaurant_id, nil))
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	db := newPermissionTestDB(t)
+	users := map[string]*models.User{}
+	for _, u := range []models.User{
+		{Username: "customer", Email: "customer@example.com", Role: models.RoleCustomer},
+		{Username: "owner", Email: "owner@example.com", Role: models.RoleCustomer, MFAEnabled: true},
+		{Username: "owner-no-mfa", Email: "owner2@example.com", Role: models.RoleCustomer},
+		{Username: "staff", Email: "staff@example.com", Role: models.RoleCustomer},
+		{Username: "admin", Email: "admin@example.com", Role: models.RoleAdmin, MFAEnabled: true},
+		{Username: "superadmin", Email: "superadmin@example.com", Role: models.RoleSuperAdmin, MFAEnabled: true},
+	} {
+		u := u
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatal(err)
+		}
+		users[u.Username] = &u
+	}
+	for _, m := range []models.RestaurantMember{
+		{RestaurantID: 1, UserID: users["owner"].ID, Role: models.RoleOwner},
+		{RestaurantID: 1, UserID: users["owner-no-mfa"].ID, Role: models.RoleOwner},
+		{RestaurantID: 1, UserID: users["staff"].ID, Role: models.RoleStaff},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		user       string
+		scopes     string
+		perm       string
+		restaurant string
+		want       int
+	}{
+		{name: "unauthenticated", perm: models.PermAccountRead, restaurant: "1", want: http.StatusUnauthorized},
+		{name: "customer permission", user: "customer", perm: models.PermAccountRead, restaurant: "1", want: http.StatusOK},
+		{name: "customer on a restaurant", user: "customer", perm: models.PermRestaurantWrite, restaurant: "1", want: http.StatusForbidden},
+		{name: "owner", user: "owner", perm: models.PermRestaurantWrite, restaurant: "1", want: http.StatusOK},
+		{name: "owner of another restaurant", user: "owner", perm: models.PermRestaurantWrite, restaurant: "2", want: http.StatusForbidden},
+		{name: "owner without 2FA", user: "owner-no-mfa", perm: models.PermRestaurantWrite, restaurant: "1", want: http.StatusForbidden},
+		{name: "staff", user: "staff", perm: models.PermMenuWrite, restaurant: "1", want: http.StatusOK},
+		{name: "staff beyond the role", user: "staff", perm: models.PermRestaurantMembers, restaurant: "1", want: http.StatusForbidden},
+		{name: "admin", user: "admin", perm: models.PermUserManage, restaurant: "1", want: http.StatusOK},
+		{name: "admin and superadmin permission", user: "admin", perm: models.PermUserRoles, restaurant: "1", want: http.StatusForbidden},
+		{name: "superadmin", user: "superadmin", perm: models.PermUserRoles, restaurant: "1", want: http.StatusOK},
+		{name: "api key with the scope", user: "owner", scopes: models.PermRestaurantWrite, perm: models.PermRestaurantWrite, restaurant: "1", want: http.StatusOK},
+		{name: "api key without the scope", user: "owner", scopes: models.PermMenuWrite, perm: models.PermRestaurantWrite, restaurant: "1", want: http.StatusForbidden},
+		{name: "api key scope beyond the role", user: "customer", scopes: models.PermRestaurantWrite, perm: models.PermRestaurantWrite, restaurant: "1", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var caller *models.CommonRequest
+			if tt.user != "" {
+				u := users[tt.user]
+				caller = &models.CommonRequest{User: *u, IsAdmin: models.IsPlatformAdmin(u.Role)}
+				if tt.scopes != "" {
+					caller.APIKey = &models.APIKey{UserID: u.ID, Scopes: tt.scopes}
+				}
+			}
+			if got := checkAccess(t, db, caller, tt.restaurant, RequirePermission(tt.perm, FromParam("id"))); got != tt.want {
+				t.Fatalf("status %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	db := newPermissionTestDB(t)
+	u := models.User{ID: 1, Role: models.RoleCustomer}
+
+	session := &models.CommonRequest{User: u}
+	if got := checkAccess(t, db, session, "1", RequireSession()); got != http.StatusOK {
+		t.Fatalf("session: status %d", got)
+	}
+	key := &models.CommonRequest{User: u, APIKey: &models.APIKey{UserID: 1, Scopes: models.PermAccountWrite}}
+	if got := checkAccess(t, db, key, "1", RequireSession(), RequirePermission(models.PermAccountWrite, nil)); got != http.StatusForbidden {
+		t.Fatalf("api key: status %d, want %d", got, http.StatusForbidden)
+	}
+}
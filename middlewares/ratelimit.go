@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"final-project/utils/ratelimit"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc picks what a rate limit is counted by. An empty key skips the limit.
+type KeyFunc func(c *gin.Context) string
+
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByJSONField counts by a field of the JSON body, e.g. the email of a login,
+// so one account cannot be attacked from many addresses.
+func ByJSONField(field string) KeyFunc {
+	return func(c *gin.Context) string {
+		return strings.ToLower(strings.TrimSpace(jsonField(c, field)))
+	}
+}
+
+// RateLimit answers 429 with Retry-After once key exceeds limit requests per
+// window. name separates the counters of different limits in one store.
+func RateLimit(store ratelimit.Store, name string, limit int, window time.Duration, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+		result, err := store.Hit(name+":"+k, limit, window)
+		if err != nil {
+			// a broken limiter backend must not take the endpoints down with it
+			log.Println("rate limit:", err)
+			c.Next()
+			return
+		}
+		if !result.Allowed {
+			TooManyRequests(c, result.RetryAfter, "")
+			return
+		}
+		c.Next()
+	}
+}
+
+// TooManyRequests aborts with 429 and a Retry-After header in whole seconds.
+// An empty message tells the client how long to wait.
+func TooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	if message == "" {
+		message = "too many requests, retry in " + strconv.Itoa(seconds) + " seconds"
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message})
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"errors"
+	"final-project/utils/ratelimit"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type failingStore struct{}
+
+func (failingStore) Hit(string, int, time.Duration) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+// newLimitedEngine answers POST /login with the body it received, behind the
+// given middlewares. No proxy is trusted.
+func newLimitedEngine(t *testing.T, handlers ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	handlers = append(handlers, func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	r.POST("/login", handlers...)
+	return r
+}
+
+func post(r *gin.Engine, remoteAddr string, forwardedFor string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	r := newLimitedEngine(t, RateLimit(ratelimit.NewMemoryStore(), "auth-ip", 2, time.Minute, ByIP))
+
+	post(r, "192.0.2.1:1000", "203.0.113.1", "")
+	post(r, "192.0.2.1:1001", "203.0.113.2", "")
+	w := post(r, "192.0.2.1:1002", "203.0.113.3", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request from one peer with spoofed X-Forwarded-For: status %d", w.Code)
+	}
+	if retry := w.Header().Get("Retry-After"); retry == "" || retry == "0" {
+		t.Fatalf("Retry-After %q", retry)
+	}
+
+	if w := post(r, "192.0.2.2:1000", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("another peer: status %d", w.Code)
+	}
+}
+
+func TestRateLimitByJSONField(t *testing.T) {
+	r := newLimitedEngine(t, RateLimit(ratelimit.NewMemoryStore(), "login-account", 1, time.Minute, ByJSONField("email")))
+
+	body := `{"email":"ana@example.com"}`
+	if w := post(r, "192.0.2.1:1000", "", body); w.Code != http.StatusOK || w.Body.String() != body {
+		t.Fatalf("first login: status %d, handler read %q", w.Code, w.Body)
+	}
+	if w := post(r, "192.0.2.2:1000", "", `{"email":" ANA@example.com "}`); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("same email in another case from another address: status %d", w.Code)
+	}
+	// no key, no limit
+	for i := 0; i < 3; i++ {
+		if w := post(r, "192.0.2.1:1000", "", `{}`); w.Code != http.StatusOK {
+			t.Fatalf("body without email: status %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitNamesKeepSeparateCounters(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	login := newLimitedEngine(t, RateLimit(store, "login", 1, time.Minute, ByIP))
+	reset := newLimitedEngine(t, RateLimit(store, "reset", 1, time.Minute, ByIP))
+
+	post(login, "192.0.2.1:1000", "", "")
+	if w := post(login, "192.0.2.1:1000", "", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second login: status %d", w.Code)
+	}
+	if w := post(reset, "192.0.2.1:1000", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("first reset after the login limit: status %d", w.Code)
+	}
+}
+
+func TestRateLimitStoreErrorLetsRequestsThrough(t *testing.T) {
+	r := newLimitedEngine(t, RateLimit(failingStore{}, "auth-ip", 1, time.Minute, ByIP))
+	for i := 0; i < 2; i++ {
+		if w := post(r, "192.0.2.1:1000", "", ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d with a broken store: status %d", i+1, w.Code)
+		}
+	}
+}
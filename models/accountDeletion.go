@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils"
+	"final-project/utils/token"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeletionAlreadyRequested = errors.New("account deletion is already scheduled")
+	ErrDeletionNotRequested     = errors.New("account deletion is not scheduled")
+)
+
+func deletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(utils.Getenv("ACCOUNT_DELETION_GRACE_DAYS", "14"))
+	if err != nil || days < 0 {
+		days = 14
+	}
+	return time.Hour * 24 * time.Duration(days)
+}
+
+// RequestDeletion schedules the account for anonymization after
+// ACCOUNT_DELETION_GRACE_DAYS. The account keeps working until then and the
+// request can be cancelled.
+func (u *User) RequestDeletion(db *gorm.DB) error {
+	if u.DeletionScheduledAt != nil {
+		return ErrDeletionAlreadyRequested
+	}
+	at := time.Now().Add(deletionGracePeriod())
+	if err := db.Model(u).Update("deletion_scheduled_at", at).Error; err != nil {
+		return err
+	}
+	u.DeletionScheduledAt = &at
+	return nil
+}
+
+func (u *User) CancelDeletion(db *gorm.DB) error {
+	if u.DeletionScheduledAt == nil {
+		return ErrDeletionNotRequested
+	}
+	if err := db.Model(u).Update("deletion_scheduled_at", nil).Error; err != nil {
+		return err
+	}
+	u.DeletionScheduledAt = nil
+	return nil
+}
+
+// Anonymize removes everything that identifies the user but keeps the row,
+// so reviews and orders still count toward restaurant statistics. Their
+// author becomes "deleted-<id>". Credentials, sessions and grants are deleted.
+func (u *User) Anonymize(db *gorm.DB) error {
+	id := strconv.FormatUint(uint64(u.ID), 10)
+	unusable, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&RefreshToken{}, &APIKey{}, &UserIdentity{}, &MFARecoveryCode{}, &PasswordResetToken{}, &RestaurantMember{}, &Session{}} {
+			if err := tx.Where("user_id = ?", u.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(u).Updates(map[string]any{
+			"username":              "deleted-" + id,
+			"email":                 "deleted-" + id + "@invalid",
+			"password":              token.HashToken(unusable),
+			"role":                  RoleCustomer,
+			"email_verified_at":     nil,
+			"verification_sent_at":  nil,
+			"mfa_enabled":           false,
+			"mfa_secret":            "",
+			"mfa_pending_secret":    "",
+			"disabled_at":           now,
+			"deletion_scheduled_at": nil,
+			"anonymized_at":         now,
+			"tokens_revoked_before": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return RecordAudit(tx, AuditMeta{}, AuditEvent{Action: AuditUserDelete, TargetType: AuditTargetUser, TargetID: id})
+	})
+}
+
+// PurgeDueDeletions anonymizes every account whose grace period is over and
+// returns how many were anonymized.
+func PurgeDueDeletions(db *gorm.DB) (int, error) {
+	users := []User{}
+	err := db.Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", time.Now()).Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+	for i, u := range users {
+		if err := u.Anonymize(db); err != nil {
+			return i, err
+		}
+	}
+	return len(users), nil
+}
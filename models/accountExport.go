@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	orderExport struct {
+		OrderHistory
+		Menus []Menu `json:"menus"`
+	}
+)
+
+// ExportUserData collects everything stored about the user, keyed by the
+// section name used for the files of the zip export. Secrets are left out.
+func ExportUserData(db *gorm.DB, u User) (map[string]any, error) {
+	reviews := []Review{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+
+	orders := []OrderHistory{}
+	if err := db.Preload("Menus").Where("user_id = ?", u.ID).Order("id").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	orderData := []orderExport{}
+	for _, o := range orders {
+		orderData = append(orderData, orderExport{OrderHistory: o, Menus: o.Menus})
+	}
+
+	restaurants := []Restaurant{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&restaurants).Error; err != nil {
+		return nil, err
+	}
+	memberships := []RestaurantMember{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	identities := []UserIdentity{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	apiKeys := []APIKey{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	sessions := []Session{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	roleChanges := []RoleChange{}
+	if err := db.Where("user_id = ?", u.ID).Order("id").Find(&roleChanges).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"account":                NewUserResponse(u),
+		"reviews":                reviews,
+		"orders":                 orderData,
+		"restaurants":            restaurants,
+		"restaurant_memberships": memberships,
+		"linked_identities":      identities,
+		"api_keys":               apiKeys,
+		"role_changes":           roleChanges,
+		"sessions":               sessions,
+		"exported_at":            time.Now(),
+	}, nil
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/token"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefix marks our keys so they are recognisable in configs and secret
+// scanners. The key is apiKeyPrefix + 8 visible characters + "_" + secret.
+const apiKeyPrefix = "fp_"
+
+// apiKeyTouchInterval limits how often LastUsedAt is written for busy keys.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrAPIKeyInvalid      = errors.New("api key is invalid, expired or revoked")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrAPIKeyInvalidScope = errors.New("unknown api key scope")
+	ErrAPIKeyNoScope      = errors.New("api key needs at least one scope")
+)
+
+type (
+	// APIKey authenticates integrations as its user, limited to Scopes. Only
+	// the hash of the key is stored, Prefix is kept to recognise it in lists.
+	APIKey struct {
+		ID         uint       `json:"id" gorm:"primary_key"`
+		UserID     uint       `json:"user_id" gorm:"not null;index"`
+		User       User       `json:"-"`
+		Name       string     `json:"name" gorm:"not null"`
+		Prefix     string     `json:"prefix" gorm:"size:16;not null;uniqueIndex"`
+		KeyHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+		Scopes     string     `json:"scopes" gorm:"not null"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+)
+
+// HasScope reports whether the key was granted perm.
+func (k *APIKey) HasScope(perm string) bool {
+	for _, scope := range strings.Split(k.Scopes, ",") {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func validScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", ErrAPIKeyNoScope
+	}
+	known := map[string]bool{}
+	for _, perm := range Permissions {
+		known[perm] = true
+	}
+	seen := map[string]bool{}
+	clean := []string{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !known[scope] {
+			return "", ErrAPIKeyInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			clean = append(clean, scope)
+		}
+	}
+	return strings.Join(clean, ","), nil
+}
+
+// CreateAPIKey stores a new key for the user and returns it. The key itself
+// can not be recovered later.
+func CreateAPIKey(db *gorm.DB, user_id uint, name string, scopes []string, expires_at *time.Time) (string, APIKey, error) {
+	joined, err := validScopes(scopes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	visible, err := token.GenerateOpaqueToken(6)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	secret, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	prefix := apiKeyPrefix + visible
+	key := prefix + "_" + secret
+	k := APIKey{
+		UserID:    user_id,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   token.HashToken(key),
+		Scopes:    joined,
+		ExpiresAt: expires_at,
+	}
+	if err := db.Create(&k).Error; err != nil {
+		return "", APIKey{}, err
+	}
+	return key, k, nil
+}
+
+// AuthenticateAPIKey returns the active key and its user.
+func AuthenticateAPIKey(db *gorm.DB, key string) (APIKey, User, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return APIKey{}, User{}, ErrAPIKeyInvalid
+	}
+	k := APIKey{}
+	err := db.Preload("User").Where("key_hash = ?", token.HashToken(key)).Take(&k).Error
+	if err != nil {
+		return APIKey{}, User{}, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && k.ExpiresAt.Before(now)) {
+		return APIKey{}, User{}, ErrAPIKeyInvalid
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > apiKeyTouchInterval {
+		if err := db.Model(&APIKey{}).Where("id = ?", k.ID).Update("last_used_at", now).Error; err != nil {
+			return APIKey{}, User{}, err
+		}
+		k.LastUsedAt = &now
+	}
+	u := k.User
+	k.User = User{}
+	return k, u, nil
+}
+
+func ListAPIKeys(db *gorm.DB, user_id uint) ([]APIKey, error) {
+	keys := []APIKey{}
+	err := db.Where("user_id = ?", user_id).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey revokes one of the user's keys. Revoked keys stay listed.
+func RevokeAPIKey(db *gorm.DB, user_id uint, id uint) error {
+	result := db.Model(&APIKey{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, user_id).Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAPIKeyHasScope(t *testing.T) {
+	k := APIKey{Scopes: PermOrderCreate + "," + PermAccountWrite}
+	tests := []struct {
+		perm string
+		want bool
+	}{
+		{PermOrderCreate, true},
+		{PermAccountWrite, true},
+		// write does not cover read
+		{PermAccountRead, false},
+		{PermReviewCreate, false},
+		{"order", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := k.HasScope(tt.perm); got != tt.want {
+			t.Errorf("HasScope(%q) = %v, want %v", tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	db := newTestDB(t, &User{}, &APIKey{})
+	u := createTestUser(t, db, "ana@example.com", true)
+
+	if _, _, err := CreateAPIKey(db, u.ID, "none", nil, nil); !errors.Is(err, ErrAPIKeyNoScope) {
+		t.Fatalf("no scopes: error %v, want %v", err, ErrAPIKeyNoScope)
+	}
+	if _, _, err := CreateAPIKey(db, u.ID, "unknown", []string{"orders:everything"}, nil); !errors.Is(err, ErrAPIKeyInvalidScope) {
+		t.Fatalf("unknown scope: error %v, want %v", err, ErrAPIKeyInvalidScope)
+	}
+
+	key, _, err := CreateAPIKey(db, u.ID, "script", []string{PermAccountRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, owner, err := AuthenticateAPIKey(db, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.ID != u.ID || !k.HasScope(PermAccountRead) || k.HasScope(PermAccountWrite) {
+		t.Fatalf("authenticated key %+v of user %d", k, owner.ID)
+	}
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"final-project/utils/query"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AuditLogin             = "auth.login"
+	AuditLoginFailed       = "auth.login_failed"
+	AuditLogout            = "auth.logout"
+	AuditRegister          = "auth.register"
+	AuditResetLinkRequest  = "auth.reset_link_request"
+	AuditPasswordReset     = "auth.password_reset"
+	AuditPasswordChange    = "auth.password_change"
+	AuditUserDisable       = "user.disable"
+	AuditUserEnable        = "user.enable"
+	AuditUserForceReset    = "user.force_password_reset"
+	AuditUserRoleChange    = "user.role_change"
+	AuditUserDelete        = "user.delete"
+	AuditUserMFADisable    = "user.mfa_disable"
+	AuditRestaurantCreate  = "restaurant.create"
+	AuditRestaurantUpdate  = "restaurant.update"
+	AuditRestaurantClose   = "restaurant.close"
+	AuditRestaurantMember  = "restaurant.member_set"
+	AuditRestaurantRemoved = "restaurant.member_remove"
+	AuditMenuCreate        = "menu.create"
+	AuditMenuDelete        = "menu.delete"
+	AuditOrderCreate       = "order.create"
+
+	AuditTargetUser       = "user"
+	AuditTargetRestaurant = "restaurant"
+	AuditTargetMenu       = "menu"
+	AuditTargetOrder      = "order"
+)
+
+var ErrAuditLogAppendOnly = errors.New("audit log entries can not be changed")
+
+type (
+	// AuditLog is an append only record of a security relevant event. ActorID
+	// is nil when no user made the change, Before and After hold the JSON of
+	// the changed fields and Details anything else worth keeping.
+	AuditLog struct {
+		ID         uint      `json:"id" gorm:"primary_key"`
+		ActorID    *uint     `json:"actor_id" gorm:"index"`
+		Action     string    `json:"action" gorm:"size:64;not null;index"`
+		TargetType string    `json:"target_type" gorm:"size:32;not null;index:idx_audit_target"`
+		TargetID   string    `json:"target_id" gorm:"size:64;index:idx_audit_target"`
+		IP         string    `json:"ip" gorm:"size:64"`
+		UserAgent  string    `json:"user_agent"`
+		Before     string    `json:"before" gorm:"type:text"`
+		After      string    `json:"after" gorm:"type:text"`
+		Details    string    `json:"details" gorm:"type:text"`
+		CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	}
+
+	// AuditMeta is who made the request being recorded.
+	AuditMeta struct {
+		ActorID   *uint
+		IP        string
+		UserAgent string
+	}
+
+	// AuditEvent is what happened. Before, After and Details are marshalled
+	// to JSON, nil values are stored empty.
+	AuditEvent struct {
+		Action     string
+		TargetType string
+		TargetID   string
+		Before     any
+		After      any
+		Details    any
+	}
+
+	// AuditFilter narrows ListAuditLogs, empty fields do not filter.
+	AuditFilter struct {
+		ActorID    *uint
+		Action     string
+		TargetType string
+		TargetID   string
+		From       *time.Time
+		To         *time.Time
+	}
+)
+
+func (AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func (AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func marshalAudit(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// AuditID formats a numeric id for AuditEvent.TargetID.
+func AuditID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// AuditEmail hashes an email for the audit log. Entries for the same address
+// can be matched without keeping the address, the log can not be scrubbed
+// when the account is anonymized.
+func AuditEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordAudit appends an entry for the event.
+func RecordAudit(db *gorm.DB, meta AuditMeta, event AuditEvent) error {
+	entry := AuditLog{
+		ActorID:    meta.ActorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		IP:         truncate(meta.IP, 64),
+		UserAgent:  truncate(meta.UserAgent, 255),
+	}
+	var err error
+	if entry.Before, err = marshalAudit(event.Before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalAudit(event.After); err != nil {
+		return err
+	}
+	if entry.Details, err = marshalAudit(event.Details); err != nil {
+		return err
+	}
+	return db.Create(&entry).Error
+}
+
+func auditQuery(db *gorm.DB, filter AuditFilter) *gorm.DB {
+	query := db.Model(&AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// ListAuditLogs returns one page of entries matching filter, newest first,
+// and the number of entries matching it.
+func ListAuditLogs(db *gorm.DB, filter AuditFilter, page query.Page) ([]AuditLog, int64, error) {
+	var total int64
+	if err := auditQuery(db, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	logs := []AuditLog{}
+	err := auditQuery(db, filter).Order("id desc").Offset(page.Offset()).Limit(page.Limit).Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// EachAuditLogBatch calls fn with every batch of entries matching filter,
+// oldest first, so large exports do not load the table at once.
+func EachAuditLogBatch(db *gorm.DB, filter AuditFilter, fn func([]AuditLog) error) error {
+	batch := []AuditLog{}
+	return auditQuery(db, filter).Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
@@ -1,8 +1,13 @@
 package models
 
+import "final-project/utils/token"
+
 type (
 	CommonRequest struct {
 		IsAdmin bool
 		User    User
+		Claims  *token.Claims
+		// APIKey is set instead of Claims when the request used an API key
+		APIKey *APIKey
 	}
 )
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/oidc"
+	"final-project/utils/token"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCStateInvalid = errors.New("login session is invalid or expired, please start again")
+	ErrOIDCNoEmail      = errors.New("the provider did not share an email address")
+	ErrOIDCLinkRequired = errors.New("an account with this email exists, login with its password and verify its email before using the provider")
+)
+
+type (
+	// UserIdentity links an account at an external OpenID Connect provider to a user.
+	UserIdentity struct {
+		ID        uint      `json:"id" gorm:"primary_key"`
+		UserID    uint      `json:"user_id" gorm:"not null;index"`
+		User      User      `json:"-"`
+		Provider  string    `json:"provider" gorm:"size:64;not null;uniqueIndex:idx_identity_subject"`
+		Subject   string    `json:"subject" gorm:"size:191;not null;uniqueIndex:idx_identity_subject"`
+		Email     string    `json:"email"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	// OIDCLoginState carries the PKCE verifier and nonce of a login in
+	// flight between the redirect to the provider and the callback.
+	OIDCLoginState struct {
+		ID        uint      `json:"id" gorm:"primary_key"`
+		StateHash string    `json:"-" gorm:"size:64;not null;uniqueIndex"`
+		Provider  string    `json:"provider" gorm:"size:64;not null"`
+		Verifier  string    `json:"-" gorm:"not null"`
+		Nonce     string    `json:"-" gorm:"not null"`
+		ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+)
+
+var usernameUnsafe = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// CreateOIDCLoginState stores a new login attempt and returns the state to
+// send to the provider with it.
+func CreateOIDCLoginState(db *gorm.DB, provider string, verifier string, nonce string) (string, error) {
+	state, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	// abandoned logins are cleaned up whenever a new one starts
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&OIDCLoginState{}).Error; err != nil {
+		return "", err
+	}
+	err = db.Create(&OIDCLoginState{
+		StateHash: token.HashToken(state),
+		Provider:  provider,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(time.Minute * 10),
+	}).Error
+	if err != nil {
+		return "", err
+	}
+	return state, nil
+}
+
+// ConsumeOIDCLoginState returns and deletes the login attempt, so a callback
+// can only be completed once.
+func ConsumeOIDCLoginState(db *gorm.DB, provider string, state string) (OIDCLoginState, error) {
+	s := OIDCLoginState{}
+	err := db.Where("state_hash = ? AND provider = ?", token.HashToken(state), provider).Take(&s).Error
+	if err != nil {
+		return OIDCLoginState{}, ErrOIDCStateInvalid
+	}
+	result := db.Delete(&OIDCLoginState{}, s.ID)
+	if result.Error != nil {
+		return OIDCLoginState{}, result.Error
+	}
+	if result.RowsAffected == 0 || s.ExpiresAt.Before(time.Now()) {
+		return OIDCLoginState{}, ErrOIDCStateInvalid
+	}
+	return s, nil
+}
+
+// UserForIdentity returns the user linked to the external identity. Unknown
+// identities are linked to the user with the same email only when both the
+// provider and this account verified it: an unverified local account may have
+// been registered by someone else to keep a password on it. Without a user
+// with that email a new customer account is created.
+func UserForIdentity(db *gorm.DB, provider string, identity oidc.Identity) (User, error) {
+	u := User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		link := UserIdentity{}
+		err := tx.Where("provider = ? AND subject = ?", provider, identity.Subject).Take(&link).Error
+		if err == nil {
+			return tx.Model(User{}).Where("id = ?", link.UserID).Take(&u).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if identity.Email == "" {
+			return ErrOIDCNoEmail
+		}
+
+		err = tx.Model(User{}).Where("email = ?", identity.Email).Take(&u).Error
+		if err == nil && (!identity.EmailVerified || !u.EmailVerified()) {
+			return ErrOIDCLinkRequired
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u, err = createIdentityUser(tx, identity)
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&UserIdentity{UserID: u.ID, Provider: provider, Subject: identity.Subject, Email: identity.Email}).Error
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+func createIdentityUser(db *gorm.DB, identity oidc.Identity) (User, error) {
+	local, _, _ := strings.Cut(strings.ToLower(identity.Email), "@")
+	local = strings.Trim(usernameUnsafe.ReplaceAllString(local, ""), "._-")
+	if local == "" {
+		local = "user"
+	}
+	suffix, err := token.GenerateOpaqueToken(4)
+	if err != nil {
+		return User{}, err
+	}
+	// the account has no usable password until the user resets it
+	unusable, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return User{}, err
+	}
+
+	u := User{
+		Username: local + "-" + strings.ToLower(usernameUnsafe.ReplaceAllString(suffix, "")),
+		Email:    identity.Email,
+		Password: unusable,
+		Role:     RoleCustomer,
+	}
+	if identity.EmailVerified {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	if _, err := u.SaveUser(db); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/oidc"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newIdentityTestDB(t *testing.T) *gorm.DB {
+	return newTestDB(t, &User{}, &UserIdentity{})
+}
+
+func createTestUser(t *testing.T, db *gorm.DB, email string, verified bool) User {
+	t.Helper()
+	u := User{Username: "local-" + email, Email: email, Password: "correct horse battery", Role: RoleCustomer}
+	if verified {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	if _, err := u.SaveUser(db); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func countIdentities(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&UserIdentity{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestUserForIdentityCreatesAccount(t *testing.T) {
+	db := newIdentityTestDB(t)
+	identity := oidc.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true}
+
+	u, err := UserForIdentity(db, "mock", identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Email != identity.Email || u.Role != RoleCustomer || !u.EmailVerified() {
+		t.Fatalf("created %+v, want a verified customer with the provider email", u)
+	}
+
+	again, err := UserForIdentity(db, "mock", identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != u.ID {
+		t.Fatalf("second login got user %d, want %d", again.ID, u.ID)
+	}
+	if n := countIdentities(t, db); n != 1 {
+		t.Fatalf("%d identities stored, want 1", n)
+	}
+}
+
+func TestUserForIdentityCreatesUnverifiedAccount(t *testing.T) {
+	db := newIdentityTestDB(t)
+	u, err := UserForIdentity(db, "mock", oidc.Identity{Subject: "sub-1", Email: "new@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.EmailVerified() {
+		t.Fatal("an email the provider did not verify was marked verified")
+	}
+}
+
+func TestUserForIdentityReturnsLinkedUser(t *testing.T) {
+	db := newIdentityTestDB(t)
+	linked := createTestUser(t, db, "linked@example.com", true)
+	if err := db.Create(&UserIdentity{UserID: linked.ID, Provider: "mock", Subject: "sub-1"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// the link wins even when the provider now reports another email
+	u, err := UserForIdentity(db, "mock", oidc.Identity{Subject: "sub-1", Email: "changed@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != linked.ID {
+		t.Fatalf("got user %d, want the linked user %d", u.ID, linked.ID)
+	}
+}
+
+func TestUserForIdentityLinksVerifiedEmail(t *testing.T) {
+	db := newIdentityTestDB(t)
+	local := createTestUser(t, db, "ana@example.com", true)
+
+	u, err := UserForIdentity(db, "mock", oidc.Identity{Subject: "sub-1", Email: "ana@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != local.ID {
+		t.Fatalf("got user %d, want the local account %d", u.ID, local.ID)
+	}
+	link := UserIdentity{}
+	if err := db.Where("provider = ? AND subject = ?", "mock", "sub-1").Take(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	if link.UserID != local.ID {
+		t.Fatalf("identity linked to %d, want %d", link.UserID, local.ID)
+	}
+}
+
+func TestUserForIdentityRefusesToLink(t *testing.T) {
+	tests := []struct {
+		name             string
+		localVerified    bool
+		providerVerified bool
+	}{
+		// someone registered the address without owning it
+		{name: "local email unverified", localVerified: false, providerVerified: true},
+		{name: "provider email unverified", localVerified: true, providerVerified: false},
+		{name: "both unverified", localVerified: false, providerVerified: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newIdentityTestDB(t)
+			createTestUser(t, db, "ana@example.com", tt.localVerified)
+
+			_, err := UserForIdentity(db, "mock", oidc.Identity{Subject: "sub-1", Email: "ana@example.com", EmailVerified: tt.providerVerified})
+			if !errors.Is(err, ErrOIDCLinkRequired) {
+				t.Fatalf("error %v, want ErrOIDCLinkRequired", err)
+			}
+			if n := countIdentities(t, db); n != 0 {
+				t.Fatalf("%d identities stored, want none", n)
+			}
+		})
+	}
+}
+
+func TestUserForIdentityNeedsEmail(t *testing.T) {
+	db := newIdentityTestDB(t)
+	_, err := UserForIdentity(db, "mock", oidc.Identity{Subject: "sub-1", EmailVerified: true})
+	if !errors.Is(err, ErrOIDCNoEmail) {
+		t.Fatalf("error %v, want ErrOIDCNoEmail", err)
+	}
+}
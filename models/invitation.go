@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/token"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvitationInvalid = errors.New("invitation is invalid, expired or for another email")
+
+type (
+	// Invitation lets an admin hand out a role that is applied when the
+	// invited email registers. Only the hash of the token is stored.
+	Invitation struct {
+		ID          uint       `json:"id" gorm:"primary_key"`
+		Email       string     `json:"email" gorm:"not null;index"`
+		Role        string     `json:"role" gorm:"not null"`
+		TokenHash   string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+		InvitedByID uint       `json:"invited_by_id" gorm:"not null"`
+		InvitedBy   User       `json:"-"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		AcceptedAt  *time.Time `json:"accepted_at"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+	}
+)
+
+func CreateInvitation(db *gorm.DB, email string, role string, invited_by uint) (string, Invitation, error) {
+	if !IsPlatformRole(role) {
+		return "", Invitation{}, ErrInvalidPlatformRole
+	}
+	plain, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", Invitation{}, err
+	}
+
+	invitation := Invitation{
+		Email:       strings.ToLower(strings.TrimSpace(email)),
+		Role:        role,
+		TokenHash:   token.HashToken(plain),
+		InvitedByID: invited_by,
+		ExpiresAt:   time.Now().Add(time.Hour * 24 * 7),
+	}
+	if err := db.Create(&invitation).Error; err != nil {
+		return "", Invitation{}, err
+	}
+	return plain, invitation, nil
+}
+
+// AcceptInvitation marks the invitation used. It only succeeds once and only
+// for the email the invitation was sent to.
+func AcceptInvitation(db *gorm.DB, plain string, email string) (Invitation, error) {
+	invitation := Invitation{}
+	err := db.Where("token_hash = ?", token.HashToken(plain)).Take(&invitation).Error
+	if err != nil {
+		return Invitation{}, ErrInvitationInvalid
+	}
+	if invitation.Email != strings.ToLower(strings.TrimSpace(email)) || invitation.ExpiresAt.Before(time.Now()) {
+		return Invitation{}, ErrInvitationInvalid
+	}
+
+	now := time.Now()
+	result := db.Model(&Invitation{}).Where("id = ? AND accepted_at IS NULL", invitation.ID).Update("accepted_at", now)
+	if result.Error != nil {
+		return Invitation{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return Invitation{}, ErrInvitationInvalid
+	}
+	invitation.AcceptedAt = &now
+	return invitation, nil
+}
@@ -12,11 +12,18 @@ type (
 		Name         string     `json:"name" gorm:"not null"`
 		Description  string     `json:"description"`
 		Price        int        `json:"price" gorm:"not null"`
+		Category     string     `json:"category"`
 		CreatedAt    time.Time  `json:"created_at"`
 		UpdatedAt    time.Time  `json:"updated_at"`
-		RestaurantID uint       `json:"restaurantID" gorm:"not null"`
+		RestaurantID uint       `json:"restaurantID" gorm:"not null;index"`
 		Restaurant   Restaurant `json:"-"`
 	}
+
+	// MenuGroup is the menus of one category, "" for menus without one.
+	MenuGroup struct {
+		Category string `json:"category"`
+		Menus    []Menu `json:"menus"`
+	}
 )
 
 func CreateMenus(db *gorm.DB, u []Menu) (int, error) {
@@ -27,6 +34,36 @@ func CreateMenus(db *gorm.DB, u []Menu) (int, error) {
 	return int(result.RowsAffected), result.Error
 }
 
+// GetMenuGroups returns the menus of a restaurant grouped by category in
+// alphabetical order, menus without a category last.
+func GetMenuGroups(db *gorm.DB, restaurant_id uint) ([]MenuGroup, error) {
+	menus := []Menu{}
+	err := db.Where("restaurant_id = ?", restaurant_id).Order("category, name, id").Find(&menus).Error
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []MenuGroup{}
+	var other *MenuGroup
+	for _, menu := range menus {
+		if menu.Category == "" {
+			if other == nil {
+				other = &MenuGroup{}
+			}
+			other.Menus = append(other.Menus, menu)
+			continue
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Category != menu.Category {
+			groups = append(groups, MenuGroup{Category: menu.Category})
+		}
+		groups[len(groups)-1].Menus = append(groups[len(groups)-1].Menus, menu)
+	}
+	if other != nil {
+		groups = append(groups, *other)
+	}
+	return groups, nil
+}
+
 func CountTotalPrice(id string, quantity int, db *gorm.DB) (Menu, int, error) {
 	var err error
 	menu := Menu{}
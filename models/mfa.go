@@ -0,0 +1,200 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"final-project/utils"
+	"final-project/utils/token"
+	"final-project/utils/totp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotPending     = errors.New("start two-factor enrollment first")
+	ErrMFAInvalidCode    = errors.New("invalid two-factor code")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+)
+
+type (
+	// MFARecoveryCode is a one-time fallback for a lost authenticator. Only
+	// the sha256 of the code is stored.
+	MFARecoveryCode struct {
+		ID        uint       `json:"id" gorm:"primary_key"`
+		UserID    uint       `json:"user_id" gorm:"not null;index"`
+		User      User       `json:"-"`
+		CodeHash  string     `json:"-" gorm:"size:64;not null;index"`
+		UsedAt    *time.Time `json:"used_at"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+)
+
+// MFARequiredFor reports whether permissions granted through role need 2FA.
+// MFA_REQUIRED_ROLES is a comma separated list of platform and restaurant roles.
+func MFARequiredFor(role string) bool {
+	for _, r := range strings.Split(utils.Getenv("MFA_REQUIRED_ROLES", "superadmin,admin,owner"), ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// StartMFAEnrollment stores a new pending secret and returns its otpauth URI.
+// The secret only becomes active once ConfirmMFAEnrollment accepts a code.
+func (u *User) StartMFAEnrollment(db *gorm.DB) (string, string, error) {
+	if u.MFAEnabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := db.Model(u).Update("mfa_pending_secret", secret).Error; err != nil {
+		return "", "", err
+	}
+	u.MFAPendingSecret = secret
+	return secret, totp.URI(secret, utils.Getenv("MFA_ISSUER", "Restaurant API"), u.Email), nil
+}
+
+// ConfirmMFAEnrollment enables 2FA when code matches the pending secret and
+// returns freshly generated recovery codes.
+func (u *User) ConfirmMFAEnrollment(db *gorm.DB, code string) ([]string, error) {
+	if u.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if u.MFAPendingSecret == "" {
+		return nil, ErrMFANotPending
+	}
+	step, ok := totp.Validate(u.MFAPendingSecret, code, time.Now())
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(u).Updates(map[string]any{
+			"mfa_enabled":        true,
+			"mfa_secret":         u.MFAPendingSecret,
+			"mfa_pending_secret": "",
+			"mfa_last_step":      step,
+		}).Error
+		if err != nil {
+			return err
+		}
+		codes, err = generateRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.MFAEnabled = true
+	u.MFASecret = u.MFAPendingSecret
+	u.MFAPendingSecret = ""
+	u.MFALastStep = step
+	return codes, nil
+}
+
+// DisableMFA turns 2FA off and deletes the secret and the recovery codes.
+// meta is the user turning it off or the admin resetting it.
+func (u *User) DisableMFA(db *gorm.DB, meta AuditMeta) error {
+	if !u.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(u).Updates(map[string]any{
+			"mfa_enabled":        false,
+			"mfa_secret":         "",
+			"mfa_pending_secret": "",
+			"mfa_last_step":      0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", u.ID).Delete(&MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, meta, AuditEvent{
+			Action:     AuditUserMFADisable,
+			TargetType: AuditTargetUser,
+			TargetID:   AuditID(u.ID),
+			Before:     map[string]bool{"mfa_enabled": true},
+			After:      map[string]bool{"mfa_enabled": false},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	u.MFAEnabled = false
+	u.MFASecret = ""
+	u.MFAPendingSecret = ""
+	u.MFALastStep = 0
+	return nil
+}
+
+// VerifyMFA accepts a current TOTP code that was not used before, or an
+// unused recovery code.
+func (u *User) VerifyMFA(db *gorm.DB, code string) error {
+	if !u.MFAEnabled {
+		return ErrMFAInvalidCode
+	}
+
+	if step, ok := totp.Validate(u.MFASecret, code, time.Now()); ok {
+		result := db.Model(&User{}).Where("id = ? AND mfa_last_step < ?", u.ID, step).Update("mfa_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMFAInvalidCode
+		}
+		u.MFALastStep = step
+		return nil
+	}
+
+	now := time.Now()
+	result := db.Model(&MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", u.ID, hashRecoveryCode(code)).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+func generateRecoveryCodes(db *gorm.DB, user_id uint) ([]string, error) {
+	if err := db.Where("user_id = ?", user_id).Delete(&MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]MFARecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+		code := raw[:5] + "-" + raw[5:10]
+		codes = append(codes, code)
+		rows = append(rows, MFARecoveryCode{UserID: user_id, CodeHash: hashRecoveryCode(code)})
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case and dashes so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return token.HashToken(normalized)
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/totp"
+	"testing"
+	"time"
+)
+
+func TestDisableMFA(t *testing.T) {
+	db := newTestDB(t, &User{}, &MFARecoveryCode{}, &AuditLog{})
+	u := createTestUser(t, db, "ana@example.com", true)
+
+	if err := u.DisableMFA(db, AuditMeta{}); !errors.Is(err, ErrMFANotEnabled) {
+		t.Fatalf("disable without 2FA: error %v, want %v", err, ErrMFANotEnabled)
+	}
+
+	secret, _, err := u.StartMFAEnrollment(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.ConfirmMFAEnrollment(db, code); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.DisableMFA(db, AuditMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	stored := User{}
+	if err := db.Take(&stored, u.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.MFAEnabled || stored.MFASecret != "" {
+		t.Fatalf("2FA still set up after disabling: enabled %v", stored.MFAEnabled)
+	}
+	var codes, entries int64
+	db.Model(&MFARecoveryCode{}).Where("user_id = ?", u.ID).Count(&codes)
+	db.Model(&AuditLog{}).Where("action = ? AND target_id = ?", AuditUserMFADisable, AuditID(u.ID)).Count(&entries)
+	if codes != 0 || entries != 1 {
+		t.Fatalf("%d recovery codes and %d audit entries left, want 0 and 1", codes, entries)
+	}
+}
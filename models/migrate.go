@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the tables of every model. Restaurants created
+// before the rating columns get them filled once.
+func Migrate(db *gorm.DB) error {
+	backfillRatings := !db.Migrator().HasColumn(&Restaurant{}, "RatingAvg")
+
+	err := db.AutoMigrate(&Restaurant{}, &Review{}, &User{}, &Menu{}, &OrderHistory{}, &RefreshToken{}, &RevokedToken{}, &RestaurantMember{}, &RoleChange{}, &Invitation{}, &PasswordResetToken{}, &MFARecoveryCode{}, &UserIdentity{}, &OIDCLoginState{}, &APIKey{}, &AuditLog{}, &Session{}, &OpeningHour{})
+	if err != nil {
+		return err
+	}
+
+	if backfillRatings {
+		return RefreshRestaurantRatings(db)
+	}
+	return nil
+}
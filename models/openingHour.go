@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidOpeningHour = errors.New("opening hours need a weekday from 0 (sunday) to 6 and times as HH:MM")
+
+type (
+	// OpeningHour is one opening period of a restaurant in minutes after
+	// midnight. ClosesAt before OpensAt means the period ends the next day.
+	OpeningHour struct {
+		ID           uint       `json:"id" gorm:"primary_key"`
+		RestaurantID uint       `json:"restaurant_id" gorm:"not null;index:idx_opening_hour"`
+		Restaurant   Restaurant `json:"-"`
+		Weekday      int        `json:"weekday" gorm:"not null;index:idx_opening_hour"`
+		OpensAt      int        `json:"opens_at" gorm:"not null"`
+		ClosesAt     int        `json:"closes_at" gorm:"not null"`
+	}
+
+	// OpeningPeriod is an OpeningHour with HH:MM times, as clients enter them.
+	OpeningPeriod struct {
+		Weekday int    `json:"weekday"`
+		Opens   string `json:"opens"`
+		Closes  string `json:"closes"`
+	}
+)
+
+// RestaurantTime is now in RESTAURANT_TIMEZONE, the zone opening hours are
+// entered in.
+func RestaurantTime() time.Time {
+	loc, err := time.LoadLocation(utils.Getenv("RESTAURANT_TIMEZONE", "Local"))
+	if err != nil {
+		loc = time.Local
+	}
+	return time.Now().In(loc)
+}
+
+// ParseClock turns HH:MM into minutes after midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, ErrInvalidOpeningHour
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func FormatOpeningHours(hours []OpeningHour) []OpeningPeriod {
+	periods := []OpeningPeriod{}
+	for _, h := range hours {
+		periods = append(periods, OpeningPeriod{Weekday: h.Weekday, Opens: FormatClock(h.OpensAt), Closes: FormatClock(h.ClosesAt)})
+	}
+	return periods
+}
+
+// IsOpenAt tells whether one of the periods covers t, the same rule as the
+// open_now filter of ListRestaurants.
+func IsOpenAt(hours []OpeningHour, t time.Time) bool {
+	weekday := int(t.Weekday())
+	yesterday := (weekday + 6) % 7
+	minute := t.Hour()*60 + t.Minute()
+	for _, h := range hours {
+		overnight := h.ClosesAt < h.OpensAt
+		if h.Weekday == weekday && h.OpensAt <= minute && (h.ClosesAt > minute || overnight) {
+			return true
+		}
+		if h.Weekday == yesterday && overnight && h.ClosesAt > minute {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOpeningHours replaces every opening period of the restaurant.
+func SetOpeningHours(db *gorm.DB, restaurant_id uint, hours []OpeningHour) error {
+	for i := range hours {
+		h := &hours[i]
+		if h.Weekday < 0 || h.Weekday > 6 || h.OpensAt < 0 || h.OpensAt >= 24*60 || h.ClosesAt < 0 || h.ClosesAt >= 24*60 || h.OpensAt == h.ClosesAt {
+			return ErrInvalidOpeningHour
+		}
+		h.ID = 0
+		h.RestaurantID = restaurant_id
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("restaurant_id = ?", restaurant_id).Delete(&OpeningHour{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+}
+
+func GetOpeningHours(db *gorm.DB, restaurant_id uint) ([]OpeningHour, error) {
+	hours := []OpeningHour{}
+	err := db.Where("restaurant_id = ?", restaurant_id).Order("weekday, opens_at").Find(&hours).Error
+	return hours, err
+}
+
+// openAtCondition matches restaurants open at t: a period that started today
+// and has not ended, or an overnight period from yesterday still running.
+func openAtCondition(db *gorm.DB, t time.Time) *gorm.DB {
+	weekday := int(t.Weekday())
+	yesterday := (weekday + 6) % 7
+	minute := t.Hour()*60 + t.Minute()
+	return db.Model(&OpeningHour{}).Select("1").
+		Where("opening_hours.restaurant_id = restaurants.id").
+		Where(db.Where("opening_hours.weekday = ? AND opening_hours.opens_at <= ? AND (opening_hours.closes_at > ? OR opening_hours.closes_at < opening_hours.opens_at)", weekday, minute, minute).
+			Or("opening_hours.weekday = ? AND opening_hours.closes_at < opening_hours.opens_at AND opening_hours.closes_at > ?", yesterday, minute))
+}
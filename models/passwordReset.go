@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils"
+	"final-project/utils/token"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrResetTokenInvalid  = errors.New("reset link is invalid or expired, please request a new one")
+	ErrTooManyResetTokens = errors.New("too many outstanding reset links")
+)
+
+type (
+	// PasswordResetToken is a single use reset link. Only the sha256 of the
+	// token is stored.
+	PasswordResetToken struct {
+		ID        uint       `json:"id" gorm:"primary_key"`
+		UserID    uint       `json:"user_id" gorm:"not null;index"`
+		User      User       `json:"-"`
+		TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+		ExpiresAt time.Time  `json:"expires_at"`
+		UsedAt    *time.Time `json:"used_at"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+)
+
+func resetTokenLifespan() time.Duration {
+	minutes, err := strconv.Atoi(utils.Getenv("RESET_TOKEN_MINUTE_LIFESPAN", "15"))
+	if err != nil || minutes <= 0 {
+		minutes = 15
+	}
+	return time.Minute * time.Duration(minutes)
+}
+
+func maxOutstandingResetTokens() int64 {
+	max, err := strconv.Atoi(utils.Getenv("RESET_TOKEN_MAX_OUTSTANDING", "3"))
+	if err != nil || max <= 0 {
+		max = 3
+	}
+	return int64(max)
+}
+
+// CreatePasswordResetToken issues a reset token unless the user already holds
+// RESET_TOKEN_MAX_OUTSTANDING unused, unexpired ones.
+func CreatePasswordResetToken(db *gorm.DB, user_id uint) (string, PasswordResetToken, error) {
+	var outstanding int64
+	err := db.Model(&PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user_id, time.Now()).
+		Count(&outstanding).Error
+	if err != nil {
+		return "", PasswordResetToken{}, err
+	}
+	if outstanding >= maxOutstandingResetTokens() {
+		return "", PasswordResetToken{}, ErrTooManyResetTokens
+	}
+
+	plain, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", PasswordResetToken{}, err
+	}
+	rt := PasswordResetToken{
+		UserID:    user_id,
+		TokenHash: token.HashToken(plain),
+		ExpiresAt: time.Now().Add(resetTokenLifespan()),
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return "", PasswordResetToken{}, err
+	}
+	return plain, rt, nil
+}
+
+// ResetPasswordWithToken consumes the token, sets the new password, burns the
+// user's other reset tokens and revokes every token issued before the reset.
+func ResetPasswordWithToken(db *gorm.DB, plain string, new_password string) (User, error) {
+	u := User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rt := PasswordResetToken{}
+		if err := tx.Where("token_hash = ?", token.HashToken(plain)).Take(&rt).Error; err != nil {
+			return ErrResetTokenInvalid
+		}
+		if rt.UsedAt != nil || rt.ExpiresAt.Before(time.Now()) {
+			return ErrResetTokenInvalid
+		}
+
+		now := time.Now()
+		result := tx.Model(&PasswordResetToken{}).Where("user_id = ? AND used_at IS NULL", rt.UserID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrResetTokenInvalid
+		}
+
+		if err := tx.Model(User{}).Where("id = ?", rt.UserID).Take(&u).Error; err != nil {
+			return err
+		}
+		return u.UpdatePassword(tx, new_password)
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Platform roles live on User.Role, restaurant roles on RestaurantMember.Role.
+// Customers keep the historical "user" value.
+const (
+	RoleSuperAdmin = "superadmin"
+	RoleAdmin      = "admin"
+	RoleCustomer   = "user"
+
+	RoleOwner = "owner"
+	RoleStaff = "staff"
+)
+
+const (
+	PermRestaurantCreate  = "restaurant:create"
+	PermRestaurantWrite   = "restaurant:write"
+	PermRestaurantMembers = "restaurant:members"
+	PermMenuWrite         = "menu:write"
+	PermOrderCreate       = "order:create"
+	PermOrderRead         = "order:read"
+	PermReviewCreate      = "review:create"
+	PermUserRoles         = "user:roles"
+	PermUserManage        = "user:manage"
+	PermAuditRead         = "audit:read"
+	PermAccountRead       = "account:read"
+	PermAccountWrite      = "account:write"
+)
+
+// Permissions lists every permission, API key scopes must be one of them.
+var Permissions = []string{
+	PermRestaurantCreate,
+	PermRestaurantWrite,
+	PermRestaurantMembers,
+	PermMenuWrite,
+	PermOrderCreate,
+	PermOrderRead,
+	PermReviewCreate,
+	PermUserRoles,
+	PermUserManage,
+	PermAuditRead,
+	PermAccountRead,
+	PermAccountWrite,
+}
+
+var (
+	ErrInvalidRestaurantRole = errors.New("restaurant role must be owner or staff")
+	ErrInvalidPlatformRole   = errors.New("role must be user, admin or superadmin")
+)
+
+// platformPermissions are granted everywhere, restaurantPermissions only for
+// the restaurant the membership belongs to. Superadmins are granted everything,
+// admins everything except superAdminPermissions.
+var (
+	superAdminPermissions = map[string]bool{
+		PermUserRoles: true,
+	}
+	platformPermissions = map[string]map[string]bool{
+		RoleCustomer: {
+			PermOrderCreate:  true,
+			PermReviewCreate: true,
+			PermAccountRead:  true,
+			PermAccountWrite: true,
+		},
+	}
+	restaurantPermissions = map[string]map[string]bool{
+		RoleOwner: {
+			PermRestaurantWrite:   true,
+			PermRestaurantMembers: true,
+			PermMenuWrite:         true,
+			PermOrderRead:         true,
+		},
+		RoleStaff: {
+			PermMenuWrite: true,
+			PermOrderRead: true,
+		},
+	}
+)
+
+type (
+	RestaurantMember struct {
+		ID           uint       `json:"id" gorm:"primary_key"`
+		RestaurantID uint       `json:"restaurant_id" gorm:"not null;uniqueIndex:idx_restaurant_member"`
+		Restaurant   Restaurant `json:"-"`
+		UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_restaurant_member"`
+		User         User       `json:"-"`
+		Role         string     `json:"role" gorm:"not null"`
+		CreatedAt    time.Time  `json:"created_at"`
+		UpdatedAt    time.Time  `json:"updated_at"`
+	}
+)
+
+func IsPlatformRole(role string) bool {
+	return role == RoleCustomer || role == RoleAdmin || role == RoleSuperAdmin
+}
+
+func IsPlatformAdmin(role string) bool {
+	return role == RoleAdmin || role == RoleSuperAdmin
+}
+
+func IsRestaurantRole(role string) bool {
+	_, ok := restaurantPermissions[role]
+	return ok
+}
+
+// HasPermission reports whether u holds perm, either platform wide or through
+// a membership of restaurant_id. restaurant_id may be empty for unscoped checks.
+func HasPermission(db *gorm.DB, u User, perm string, restaurant_id string) (bool, error) {
+	role, err := GrantingRole(db, u, perm, restaurant_id)
+	return role != "", err
+}
+
+// GrantingRole returns the platform or restaurant role that grants perm to u,
+// or "" when none does.
+func GrantingRole(db *gorm.DB, u User, perm string, restaurant_id string) (string, error) {
+	// every account holds the customer permissions, whatever its role
+	if platformPermissions[RoleCustomer][perm] {
+		return RoleCustomer, nil
+	}
+	if u.Role == RoleSuperAdmin || (u.Role == RoleAdmin && !superAdminPermissions[perm]) || platformPermissions[u.Role][perm] {
+		return u.Role, nil
+	}
+	if restaurant_id == "" {
+		return "", nil
+	}
+
+	member := RestaurantMember{}
+	err := db.Where("restaurant_id = ? AND user_id = ?", restaurant_id, u.ID).Take(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !restaurantPermissions[member.Role][perm] {
+		return "", nil
+	}
+	return member.Role, nil
+}
+
+// SetRestaurantMember grants role on the restaurant, replacing any previous grant.
+func SetRestaurantMember(db *gorm.DB, restaurant_id uint, user_id uint, role string) (RestaurantMember, error) {
+	if !IsRestaurantRole(role) {
+		return RestaurantMember{}, ErrInvalidRestaurantRole
+	}
+	member := RestaurantMember{}
+	err := db.Where(RestaurantMember{RestaurantID: restaurant_id, UserID: user_id}).
+		Assign(RestaurantMember{Role: role}).
+		FirstOrCreate(&member).Error
+	return member, err
+}
+
+func RemoveRestaurantMember(db *gorm.DB, restaurant_id string, user_id string) error {
+	result := db.Where("restaurant_id = ? AND user_id = ?", restaurant_id, user_id).Delete(&RestaurantMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
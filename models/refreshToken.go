@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils"
+	"final-project/utils/token"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, please login again")
+)
+
+type (
+	// RefreshToken is one link in a rotation chain. Every token issued from the
+	// same login shares a FamilyID so a replayed token can revoke the chain.
+	RefreshToken struct {
+		ID        uint       `json:"id" gorm:"primary_key"`
+		UserID    uint       `json:"user_id" gorm:"not null;index"`
+		User      User       `json:"-"`
+		FamilyID  string     `json:"family_id" gorm:"size:64;not null;index"`
+		TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+		ExpiresAt time.Time  `json:"expires_at"`
+		UsedAt    *time.Time `json:"used_at"`
+		RevokedAt *time.Time `json:"revoked_at"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt time.Time  `json:"updated_at"`
+	}
+)
+
+func refreshTokenLifespan() time.Duration {
+	days, err := strconv.Atoi(utils.Getenv("REFRESH_TOKEN_DAY_LIFESPAN", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Hour * 24 * time.Duration(days)
+}
+
+// CreateRefreshToken issues a new refresh token for the user and returns the
+// plain value. An empty familyID starts a new family.
+func CreateRefreshToken(db *gorm.DB, user_id uint, family_id string) (string, RefreshToken, error) {
+	plain, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", RefreshToken{}, err
+	}
+	if family_id == "" {
+		family_id, err = token.GenerateOpaqueToken(16)
+		if err != nil {
+			return "", RefreshToken{}, err
+		}
+	}
+
+	rt := RefreshToken{
+		UserID:    user_id,
+		FamilyID:  family_id,
+		TokenHash: token.HashToken(plain),
+		ExpiresAt: time.Now().Add(refreshTokenLifespan()),
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return "", RefreshToken{}, err
+	}
+	return plain, rt, nil
+}
+
+// RotateRefreshToken consumes a refresh token and issues its successor in the
+// same family. Presenting a token that was already used revokes the family.
+func RotateRefreshToken(db *gorm.DB, plain string) (string, RefreshToken, error) {
+	var next string
+	var issued RefreshToken
+	var reused bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current := RefreshToken{}
+		if err := tx.Where("token_hash = ?", token.HashToken(plain)).Take(&current).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+		if current.RevokedAt != nil || current.ExpiresAt.Before(time.Now()) {
+			return ErrRefreshTokenInvalid
+		}
+
+		now := time.Now()
+		result := tx.Model(&RefreshToken{}).Where("id = ? AND used_at IS NULL", current.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return RevokeRefreshFamily(tx, current.FamilyID)
+		}
+
+		var err error
+		next, issued, err = CreateRefreshToken(tx, current.UserID, current.FamilyID)
+		return err
+	})
+	if err != nil {
+		return "", RefreshToken{}, err
+	}
+	if reused {
+		return "", RefreshToken{}, ErrRefreshTokenReused
+	}
+	return next, issued, nil
+}
+
+// RevokeRefreshFamily revokes every refresh token that descends from the same
+// login and the session of that login.
+func RevokeRefreshFamily(db *gorm.DB, family_id string) error {
+	now := time.Now()
+	err := db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", family_id).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", family_id).
+		Update("revoked_at", now).Error
+}
+
+// RevokeRefreshFamilyByToken revokes the family the given plain token belongs
+// to and returns the token.
+func RevokeRefreshFamilyByToken(db *gorm.DB, plain string) (RefreshToken, error) {
+	rt := RefreshToken{}
+	if err := db.Where("token_hash = ?", token.HashToken(plain)).Take(&rt).Error; err != nil {
+		return RefreshToken{}, ErrRefreshTokenInvalid
+	}
+	return rt, RevokeRefreshFamily(db, rt.FamilyID)
+}
+
+// RevokeUserRefreshTokens revokes every refresh token and session of the
+// user, across all families.
+func RevokeUserRefreshTokens(db *gorm.DB, user_id uint) error {
+	now := time.Now()
+	err := db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", user_id).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", user_id).
+		Update("revoked_at", now).Error
+}
@@ -1,28 +1,62 @@
 package models
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+var ErrInvalidLocation = errors.New("a location needs both latitude (-90 to 90) and longitude (-180 to 180), and the delivery radius can not be negative")
+
 type (
+	// Address is the street address of a restaurant, the city is kept in
+	// Restaurant.City.
+	Address struct {
+		Street     string `json:"street"`
+		PostalCode string `json:"postal_code"`
+		Region     string `json:"region"`
+		Country    string `json:"country"`
+	}
+
 	Restaurant struct {
-		ID        uint      `gorm:"primary_key" json:"id"`
-		Name      string    `json:"name"`
-		City      string    `json:"city"`
-		Reviews   []Review  `json:"-"`
-		Menus     []Menu    `json:"-"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		UserID    uint      `json:"user_id" gorm:"not null"`
-		User      User      `json:"-"`
+		ID      uint    `gorm:"primary_key" json:"id"`
+		Name    string  `json:"name"`
+		City    string  `json:"city" gorm:"index"`
+		Cuisine string  `json:"cuisine" gorm:"index"`
+		Address Address `json:"address" gorm:"embedded;embeddedPrefix:address_"`
+		// Latitude and Longitude are nil until the owner sets a location,
+		// the index serves the bounding box of ListNearbyRestaurants
+		Latitude  *float64 `json:"latitude" gorm:"index:idx_restaurant_location"`
+		Longitude *float64 `json:"longitude" gorm:"index:idx_restaurant_location"`
+		// DeliveryRadiusKm is how far the restaurant delivers, 0 when it does not
+		DeliveryRadiusKm float64 `json:"delivery_radius_km" gorm:"not null;default:0"`
+		// RatingAvg and TotalReview aggregate the reviews, they are updated
+		// with every review so listings need no join
+		RatingAvg   float64   `json:"rating_avg" gorm:"not null;default:0;index"`
+		TotalReview int       `json:"total_review" gorm:"not null;default:0;index"`
+		Reviews     []Review  `json:"-"`
+		Menus       []Menu    `json:"-"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		// DeletedAt closes the restaurant. Closed restaurants are left out of
+		// every query except Unscoped ones, orders and reviews keep their id.
+		DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+		UserID    uint           `json:"user_id" gorm:"not null"`
+		User      User           `json:"-"`
 	}
 )
 
 func (u *Restaurant) SaveRestaurant(db *gorm.DB) (*Restaurant, error) {
 
-	var err error = db.Create(&u).Error
+	var err error = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		_, err := SetRestaurantMember(tx, u.ID, u.UserID, RoleOwner)
+		return err
+	})
 	if err != nil {
 		return &Restaurant{}, err
 	}
@@ -38,3 +72,73 @@ func SearchRestaurant(id string, db *gorm.DB) (Restaurant, error) {
 	}
 	return resto, nil
 }
+
+// ValidateLocation checks a location and delivery radius, latitude and
+// longitude go together.
+func ValidateLocation(latitude *float64, longitude *float64, delivery_radius_km float64) error {
+	if (latitude == nil) != (longitude == nil) || delivery_radius_km < 0 {
+		return ErrInvalidLocation
+	}
+	if latitude != nil && (*latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180) {
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+// RestaurantChanges are the fields UpdateRestaurant changes, nil ones are kept.
+type RestaurantChanges struct {
+	Name             *string
+	City             *string
+	Cuisine          *string
+	Address          *Address
+	Latitude         *float64
+	Longitude        *float64
+	DeliveryRadiusKm *float64
+}
+
+// UpdateRestaurant changes the fields that are not nil.
+func (u *Restaurant) UpdateRestaurant(db *gorm.DB, changes RestaurantChanges) error {
+	radius := u.DeliveryRadiusKm
+	if changes.DeliveryRadiusKm != nil {
+		radius = *changes.DeliveryRadiusKm
+	}
+	if err := ValidateLocation(changes.Latitude, changes.Longitude, radius); err != nil {
+		return err
+	}
+
+	updates := map[string]any{}
+	if changes.Name != nil {
+		updates["name"] = *changes.Name
+	}
+	if changes.City != nil {
+		updates["city"] = *changes.City
+	}
+	if changes.Cuisine != nil {
+		updates["cuisine"] = strings.ToLower(strings.TrimSpace(*changes.Cuisine))
+	}
+	if changes.Address != nil {
+		updates["address_street"] = changes.Address.Street
+		updates["address_postal_code"] = changes.Address.PostalCode
+		updates["address_region"] = changes.Address.Region
+		updates["address_country"] = changes.Address.Country
+	}
+	if changes.Latitude != nil {
+		updates["latitude"] = *changes.Latitude
+		updates["longitude"] = *changes.Longitude
+	}
+	if changes.DeliveryRadiusKm != nil {
+		updates["delivery_radius_km"] = radius
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := db.Model(u).Updates(updates).Error; err != nil {
+		return err
+	}
+	return db.Model(u).Where("id = ?", u.ID).Take(u).Error
+}
+
+// CloseRestaurant soft deletes the restaurant.
+func (u *Restaurant) CloseRestaurant(db *gorm.DB) error {
+	return db.Delete(u).Error
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/query"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RestaurantSortRating  = "rating_avg"
+	RestaurantSortReviews = "total_review"
+	RestaurantSortName    = "name"
+	RestaurantSortNewest  = "newest"
+)
+
+// RestaurantSortKeys lists the sort keys of ListRestaurants, the first is the default.
+var RestaurantSortKeys = []string{RestaurantSortRating, RestaurantSortReviews, RestaurantSortName, RestaurantSortNewest}
+
+// restaurantSortColumns maps a sort key to its expression and whether it
+// sorts descending by default.
+var restaurantSortColumns = map[string]struct {
+	expr string
+	desc bool
+}{
+	RestaurantSortRating:  {"restaurants.rating_avg", true},
+	RestaurantSortReviews: {"restaurants.total_review", true},
+	RestaurantSortName:    {"restaurants.name", false},
+	RestaurantSortNewest:  {"restaurants.created_at", true},
+}
+
+type (
+	// RestaurantFilter narrows ListRestaurants, empty fields do not filter.
+	RestaurantFilter struct {
+		City      string
+		Cuisine   string
+		MinRating *float64
+		// OpenAt keeps restaurants open at that time
+		OpenAt *time.Time
+	}
+
+	// RestaurantSort orders ListRestaurants by Key, Order is "asc", "desc" or
+	// "" for the key's natural order.
+	RestaurantSort struct {
+		Key   string
+		Order string
+	}
+
+	RestaurantListItem struct {
+		ID          uint      `json:"id"`
+		Name        string    `json:"name"`
+		City        string    `json:"city"`
+		Cuisine     string    `json:"cuisine"`
+		UserID      uint      `json:"user_id"`
+		RatingAvg   float64   `json:"rating_avg"`
+		TotalReview int       `json:"total_review"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	// restaurantCursor is the sort value and id of the last item of a page.
+	restaurantCursor struct {
+		Key   string  `json:"k"`
+		Order string  `json:"o"`
+		Float float64 `json:"f,omitempty"`
+		Text  string  `json:"s,omitempty"`
+		ID    uint    `json:"id"`
+	}
+)
+
+func (s RestaurantSort) desc() bool {
+	if s.Order == "" {
+		return restaurantSortColumns[s.Key].desc
+	}
+	return s.Order == "desc"
+}
+
+func filterRestaurants(db *gorm.DB, filter RestaurantFilter) *gorm.DB {
+	q := db.Model(&Restaurant{})
+	if filter.City != "" {
+		q = q.Where("restaurants.city = ?", filter.City)
+	}
+	if filter.Cuisine != "" {
+		q = q.Where("restaurants.cuisine = ?", filter.Cuisine)
+	}
+	if filter.MinRating != nil {
+		q = q.Where("restaurants.rating_avg >= ?", *filter.MinRating)
+	}
+	if filter.OpenAt != nil {
+		q = q.Where("EXISTS (?)", openAtCondition(db, *filter.OpenAt))
+	}
+	return q
+}
+
+// ListRestaurants returns one page of open restaurants with their rating,
+// the number of restaurants matching filter and the cursor of the next page,
+// "" on the last page. A page with a Cursor continues the listing that
+// returned it, the sort of the cursor wins over sort.
+func ListRestaurants(db *gorm.DB, filter RestaurantFilter, sort RestaurantSort, page query.Page) ([]RestaurantListItem, int64, string, error) {
+	var after *restaurantCursor
+	if page.Cursor != "" {
+		after = &restaurantCursor{}
+		if err := query.DecodeCursor(page.Cursor, after); err != nil {
+			return nil, 0, "", err
+		}
+		if _, ok := restaurantSortColumns[after.Key]; !ok {
+			return nil, 0, "", query.InvalidCursor()
+		}
+		sort = RestaurantSort{Key: after.Key, Order: after.Order}
+	}
+	column, ok := restaurantSortColumns[sort.Key]
+	if !ok {
+		return nil, 0, "", errors.New("unknown sort key " + sort.Key)
+	}
+
+	var total int64
+	if err := filterRestaurants(db, filter).Count(&total).Error; err != nil {
+		return nil, 0, "", err
+	}
+
+	direction, cmp := "ASC", ">"
+	if sort.desc() {
+		direction, cmp = "DESC", "<"
+	}
+	q := filterRestaurants(db, filter).
+		Select("restaurants.id, restaurants.name, restaurants.city, restaurants.cuisine, restaurants.user_id, restaurants.created_at, restaurants.rating_avg, restaurants.total_review").
+		Order(column.expr + " " + direction).
+		Order("restaurants.id " + direction)
+	if after != nil {
+		var value any = after.Float
+		if sort.Key == RestaurantSortName {
+			value = after.Text
+		}
+		if sort.Key == RestaurantSortNewest {
+			t, err := time.Parse(time.RFC3339Nano, after.Text)
+			if err != nil {
+				return nil, 0, "", query.InvalidCursor()
+			}
+			value = t
+		}
+		q = q.Where("("+column.expr+" "+cmp+" ?) OR ("+column.expr+" = ? AND restaurants.id "+cmp+" ?)", value, value, after.ID)
+	} else {
+		q = q.Offset(page.Offset())
+	}
+
+	// one extra row tells whether there is a next page
+	items := []RestaurantListItem{}
+	if err := q.Limit(page.Limit + 1).Find(&items).Error; err != nil {
+		return nil, 0, "", err
+	}
+	if len(items) <= page.Limit {
+		return items, total, "", nil
+	}
+	items = items[:page.Limit]
+
+	last := items[len(items)-1]
+	next := restaurantCursor{Key: sort.Key, Order: sort.Order, ID: last.ID}
+	switch sort.Key {
+	case RestaurantSortRating:
+		next.Float = last.RatingAvg
+	case RestaurantSortReviews:
+		next.Float = float64(last.TotalReview)
+	case RestaurantSortName:
+		next.Text = last.Name
+	case RestaurantSortNewest:
+		next.Text = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+	cursor, err := query.EncodeCursor(next)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return items, total, cursor, nil
+}
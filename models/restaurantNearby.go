@@ -0,0 +1,128 @@
+package models
+
+import (
+	"final-project/utils/query"
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+const earthRadiusKm = 6371.0
+
+type (
+	// NearbyFilter is the point and radius of ListNearbyRestaurants.
+	NearbyFilter struct {
+		Latitude  float64
+		Longitude float64
+		RadiusKm  float64
+		// Delivers keeps restaurants whose delivery radius reaches the point
+		Delivers bool
+	}
+
+	NearbyRestaurant struct {
+		RestaurantListItem
+		Address          Address `json:"address" gorm:"embedded;embeddedPrefix:address_"`
+		Latitude         float64 `json:"latitude"`
+		Longitude        float64 `json:"longitude"`
+		DeliveryRadiusKm float64 `json:"delivery_radius_km"`
+		DistanceKm       float64 `json:"distance_km" gorm:"-"`
+	}
+
+	// boundingBox holds every point within a radius, MinLongitude is above
+	// MaxLongitude when the box crosses the antimeridian.
+	boundingBox struct {
+		MinLatitude  float64
+		MaxLatitude  float64
+		MinLongitude float64
+		MaxLongitude float64
+		// AllLongitudes is set when the box reaches a pole
+		AllLongitudes bool
+	}
+)
+
+// DistanceKm is the haversine distance between two points.
+func DistanceKm(lat1 float64, lng1 float64, lat2 float64, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func newBoundingBox(lat float64, lng float64, radius_km float64) boundingBox {
+	dLat := radius_km / earthRadiusKm * 180 / math.Pi
+	box := boundingBox{MinLatitude: lat - dLat, MaxLatitude: lat + dLat}
+	if box.MinLatitude <= -90 || box.MaxLatitude >= 90 {
+		box.MinLatitude = math.Max(box.MinLatitude, -90)
+		box.MaxLatitude = math.Min(box.MaxLatitude, 90)
+		box.AllLongitudes = true
+		return box
+	}
+
+	dLng := math.Asin(math.Sin(radius_km/earthRadiusKm)/math.Cos(lat*math.Pi/180)) * 180 / math.Pi
+	box.MinLongitude = lng - dLng
+	box.MaxLongitude = lng + dLng
+	if box.MinLongitude < -180 {
+		box.MinLongitude += 360
+	}
+	if box.MaxLongitude > 180 {
+		box.MaxLongitude -= 360
+	}
+	return box
+}
+
+// ListNearbyRestaurants returns one page of the open restaurants within
+// filter.RadiusKm of the point, nearest first, and how many there are. The
+// database only narrows the candidates to a bounding box on the indexed
+// latitude and longitude, distances are computed here so the query needs no
+// trigonometry from the database.
+func ListNearbyRestaurants(db *gorm.DB, filter NearbyFilter, page query.Page) ([]NearbyRestaurant, int64, error) {
+	box := newBoundingBox(filter.Latitude, filter.Longitude, filter.RadiusKm)
+	q := db.Model(&Restaurant{}).
+		Select("restaurants.id, restaurants.name, restaurants.city, restaurants.cuisine, restaurants.user_id, restaurants.created_at, restaurants.rating_avg, restaurants.total_review, restaurants.address_street, restaurants.address_postal_code, restaurants.address_region, restaurants.address_country, restaurants.latitude, restaurants.longitude, restaurants.delivery_radius_km").
+		Where("restaurants.latitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude).
+		Where("restaurants.longitude IS NOT NULL")
+	switch {
+	case box.AllLongitudes:
+	case box.MinLongitude > box.MaxLongitude:
+		q = q.Where("restaurants.longitude >= ? OR restaurants.longitude <= ?", box.MinLongitude, box.MaxLongitude)
+	default:
+		q = q.Where("restaurants.longitude BETWEEN ? AND ?", box.MinLongitude, box.MaxLongitude)
+	}
+	if filter.Delivers {
+		q = q.Where("restaurants.delivery_radius_km > 0")
+	}
+
+	candidates := []NearbyRestaurant{}
+	if err := q.Find(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// the corners of the box are farther than the radius
+	nearby := []NearbyRestaurant{}
+	for _, r := range candidates {
+		r.DistanceKm = DistanceKm(filter.Latitude, filter.Longitude, r.Latitude, r.Longitude)
+		if r.DistanceKm > filter.RadiusKm || (filter.Delivers && r.DistanceKm > r.DeliveryRadiusKm) {
+			continue
+		}
+		nearby = append(nearby, r)
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].DistanceKm != nearby[j].DistanceKm {
+			return nearby[i].DistanceKm < nearby[j].DistanceKm
+		}
+		return nearby[i].ID < nearby[j].ID
+	})
+
+	total := int64(len(nearby))
+	start := page.Offset()
+	if start > len(nearby) {
+		start = len(nearby)
+	}
+	end := start + page.Limit
+	if end > len(nearby) {
+		end = len(nearby)
+	}
+	return nearby[start:end], total, nil
+}
@@ -1,7 +1,6 @@
 package models
 
 import (
-	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,13 +13,54 @@ type (
 		Content      string     `json:"content"`
 		UserID       uint       `json:"userID" gorm:"not null"`
 		User         User       `json:"-"`
-		RestaurantID uint       `json:"restaurantID" gorm:"not null"`
+		RestaurantID uint       `json:"restaurantID" gorm:"not null;index"`
 		Restaurant   Restaurant `json:"-"`
 		CreatedAt    time.Time  `json:"created_at"`
 		UpdatedAt    time.Time  `json:"updated_at"`
 	}
+
+	// RatingSummary is the rating of a restaurant, Distribution counts the
+	// reviews of each rating.
+	RatingSummary struct {
+		Average      float64     `json:"average"`
+		Total        int         `json:"total"`
+		Distribution map[int]int `json:"distribution"`
+	}
+
+	// ReviewItem is a review as shown on a restaurant page.
+	ReviewItem struct {
+		ID        uint      `json:"id"`
+		Rating    int       `json:"rating"`
+		Content   string    `json:"content"`
+		Username  string    `json:"username"`
+		CreatedAt time.Time `json:"created_at"`
+	}
 )
 
+// The hooks refresh the rating of the review's restaurant in the transaction
+// that changes the review. They need RestaurantID, so updates and deletes go
+// through a loaded review rather than a bare where clause.
+func (review *Review) AfterCreate(tx *gorm.DB) error {
+	return review.refreshRestaurantRating(tx)
+}
+
+func (review *Review) AfterUpdate(tx *gorm.DB) error {
+	return review.refreshRestaurantRating(tx)
+}
+
+func (review *Review) AfterDelete(tx *gorm.DB) error {
+	return review.refreshRestaurantRating(tx)
+}
+
+func (review *Review) refreshRestaurantRating(tx *gorm.DB) error {
+	if review.RestaurantID == 0 {
+		return nil
+	}
+	return refreshRatings(tx.Session(&gorm.Session{NewDB: true}).Model(&Restaurant{}).Unscoped().Where("id = ?", review.RestaurantID))
+}
+
+// CreateReview saves the review, its hook refreshes the rating of the
+// restaurant in the same transaction.
 func (review *Review) CreateReview(db *gorm.DB) (*Review, error) {
 
 	var err error = db.Create(&review).Error
@@ -30,19 +70,48 @@ func (review *Review) CreateReview(db *gorm.DB) (*Review, error) {
 	return review, nil
 }
 
-func SearchReviewByResto(id uint, db *gorm.DB) (float64, int, error) {
-	var err error
-	var result struct {
-		RatingAvg   float64 `json:"rating_avg"`
-		TotalReview int     `json:"total_review"`
-	}
-	err = db.Model(&Review{}).Select("AVG(rating) as rating_avg, COUNT(1) as total_review").Where("restaurant_id = ?", id).Take(&result).Error
+// GetRatingSummary completes the rating columns of the restaurant with the
+// number of reviews per rating.
+func GetRatingSummary(db *gorm.DB, restaurant Restaurant) (RatingSummary, error) {
+	summary := RatingSummary{Average: restaurant.RatingAvg, Total: restaurant.TotalReview, Distribution: map[int]int{}}
+	rows := []struct {
+		Rating int
+		Total  int
+	}{}
+	err := db.Model(&Review{}).Select("rating, COUNT(1) AS total").Where("restaurant_id = ?", restaurant.ID).Group("rating").Find(&rows).Error
 	if err != nil {
-		return 0, 0, err
+		return summary, err
 	}
-	fmt.Println(result)
-	if result.TotalReview > 0 {
-		return result.RatingAvg, result.TotalReview, nil
+	for _, row := range rows {
+		summary.Distribution[row.Rating] = row.Total
 	}
-	return 0, result.TotalReview, nil
+	return summary, nil
+}
+
+// RecentReviews returns the latest reviews of a restaurant, newest first.
+func RecentReviews(db *gorm.DB, restaurant_id uint, limit int) ([]ReviewItem, error) {
+	reviews := []ReviewItem{}
+	err := db.Model(&Review{}).
+		Select("reviews.id, reviews.rating, reviews.content, users.username, reviews.created_at").
+		Joins("LEFT JOIN users ON users.id = reviews.user_id").
+		Where("reviews.restaurant_id = ?", restaurant_id).
+		Order("reviews.created_at DESC, reviews.id DESC").
+		Limit(limit).
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// RefreshRestaurantRatings recomputes the rating of every restaurant, closed
+// ones included, from their reviews.
+func RefreshRestaurantRatings(db *gorm.DB) error {
+	return refreshRatings(db.Model(&Restaurant{}).Unscoped().Where("1 = 1"))
+}
+
+// refreshRatings sets the rating columns of the restaurants matched by q.
+// UpdateColumns keeps updated_at, a new review does not edit the restaurant.
+func refreshRatings(q *gorm.DB) error {
+	return q.UpdateColumns(map[string]any{
+		"rating_avg":   gorm.Expr("(SELECT COALESCE(AVG(reviews.rating), 0) FROM reviews WHERE reviews.restaurant_id = restaurants.id)"),
+		"total_review": gorm.Expr("(SELECT COUNT(1) FROM reviews WHERE reviews.restaurant_id = restaurants.id)"),
+	}).Error
 }
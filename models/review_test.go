@@ -0,0 +1,171 @@
+package models
+
+import (
+	"final-project/utils/query"
+	"fmt"
+	"math"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func newRatingTestDB(tb testing.TB) *gorm.DB {
+	return newTestDB(tb, &User{}, &Restaurant{}, &Review{})
+}
+
+func createTestRestaurant(tb testing.TB, db *gorm.DB, name string) Restaurant {
+	tb.Helper()
+	r := Restaurant{Name: name, City: "Jakarta", UserID: 1}
+	if err := db.Create(&r).Error; err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
+
+func assertRating(t *testing.T, db *gorm.DB, restaurant_id uint, avg float64, total int) {
+	t.Helper()
+	r := Restaurant{}
+	if err := db.Unscoped().Take(&r, restaurant_id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.RatingAvg-avg) > 1e-9 || r.TotalReview != total {
+		t.Fatalf("rating_avg %v total_review %d, want %v and %d", r.RatingAvg, r.TotalReview, avg, total)
+	}
+}
+
+func TestReviewChangesRefreshRatings(t *testing.T) {
+	db := newRatingTestDB(t)
+	resto := createTestRestaurant(t, db, "Warung")
+	other := createTestRestaurant(t, db, "Kedai")
+	assertRating(t, db, resto.ID, 0, 0)
+
+	// CreateReview callers set the association, not the id
+	first := Review{Rating: 4, UserID: 1, Restaurant: resto}
+	if _, err := first.CreateReview(db); err != nil {
+		t.Fatal(err)
+	}
+	second := Review{Rating: 2, UserID: 2, RestaurantID: resto.ID}
+	if _, err := second.CreateReview(db); err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 3, 2)
+
+	if err := db.Model(&second).Update("rating", 5).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 4.5, 2)
+
+	if err := db.Delete(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 5, 1)
+
+	if err := db.Delete(&second).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 0, 0)
+
+	// other restaurants are left alone
+	assertRating(t, db, other.ID, 0, 0)
+}
+
+func TestReviewOnClosedRestaurantRefreshesRatings(t *testing.T) {
+	db := newRatingTestDB(t)
+	resto := createTestRestaurant(t, db, "Warung")
+	review := Review{Rating: 3, UserID: 1, RestaurantID: resto.ID}
+	if _, err := review.CreateReview(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := resto.CloseRestaurant(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Delete(&review).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 0, 0)
+}
+
+func TestRefreshRestaurantRatings(t *testing.T) {
+	db := newRatingTestDB(t)
+	resto := createTestRestaurant(t, db, "Warung")
+	// rows written before the columns existed, without the hooks
+	reviews := []Review{{Rating: 1, UserID: 1, RestaurantID: resto.ID}, {Rating: 4, UserID: 2, RestaurantID: resto.ID}}
+	if err := db.Session(&gorm.Session{SkipHooks: true}).Create(&reviews).Error; err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 0, 0)
+
+	if err := RefreshRestaurantRatings(db); err != nil {
+		t.Fatal(err)
+	}
+	assertRating(t, db, resto.ID, 2.5, 2)
+}
+
+// seedRatings stores restaurants with reviewsEach reviews, the rating columns
+// filled the way the migration backfill does.
+func seedRatings(b *testing.B, db *gorm.DB, restaurants int, reviewsEach int) {
+	b.Helper()
+	restos := make([]Restaurant, restaurants)
+	for i := range restos {
+		restos[i] = Restaurant{Name: fmt.Sprintf("Restaurant %d", i), City: "Jakarta", UserID: 1}
+	}
+	if err := db.CreateInBatches(&restos, 500).Error; err != nil {
+		b.Fatal(err)
+	}
+	reviews := make([]Review, 0, restaurants*reviewsEach)
+	for _, r := range restos {
+		for i := 0; i < reviewsEach; i++ {
+			reviews = append(reviews, Review{Rating: 1 + (int(r.ID)+i)%5, UserID: uint(i + 1), RestaurantID: r.ID})
+		}
+	}
+	if err := db.Session(&gorm.Session{SkipHooks: true}).CreateInBatches(&reviews, 500).Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := RefreshRestaurantRatings(db); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkRestaurantListing compares the listing before the rating columns,
+// one AVG/COUNT query per restaurant, with the listing reading the columns.
+func BenchmarkRestaurantListing(b *testing.B) {
+	const restaurants = 3000
+	db := newRatingTestDB(b)
+	seedRatings(b, db, restaurants, 5)
+
+	b.Run("per_restaurant_query", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			var list []struct {
+				ID   uint
+				Name string
+				City string
+			}
+			if err := db.Model(&Restaurant{}).Find(&list).Error; err != nil {
+				b.Fatal(err)
+			}
+			for _, r := range list {
+				var result struct {
+					RatingAvg   float64
+					TotalReview int
+				}
+				err := db.Model(&Review{}).Select("AVG(rating) as rating_avg, COUNT(1) as total_review").Where("restaurant_id = ?", r.ID).Take(&result).Error
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("rating_columns", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			items, _, _, err := ListRestaurants(db, RestaurantFilter{}, RestaurantSort{Key: RestaurantSortRating}, query.Page{Number: 1, Limit: restaurants})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(items) != restaurants {
+				b.Fatalf("listed %d restaurants, want %d", len(items), restaurants)
+			}
+		}
+	})
+}
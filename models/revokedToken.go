@@ -0,0 +1,46 @@
+package models
+
+import (
+	"final-project/utils/token"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	RevokedToken struct {
+		JTI       string    `json:"jti" gorm:"primary_key;size:64"`
+		ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+)
+
+var _ token.RevocationStore = (*GormRevocationStore)(nil)
+
+// GormRevocationStore is a token.RevocationStore shared by every instance
+// that talks to the same database.
+type GormRevocationStore struct {
+	db *gorm.DB
+}
+
+func NewGormRevocationStore(db *gorm.DB) *GormRevocationStore {
+	return &GormRevocationStore{db: db}
+}
+
+func (s *GormRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+	if err != nil {
+		return err
+	}
+	return s.db.Where("expires_at < ?", time.Now()).Delete(&RevokedToken{}).Error
+}
+
+func (s *GormRevocationStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := s.db.Model(&RevokedToken{}).Where("jti = ? AND expires_at > ?", jti, time.Now()).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleChangeByAdmin      = "admin"
+	RoleChangeByBootstrap  = "bootstrap"
+	RoleChangeByInvitation = "invitation"
+)
+
+type (
+	// RoleChange records every change of User.Role; ChangedByID is nil when no
+	// user made the change, e.g. the bootstrap command.
+	RoleChange struct {
+		ID          uint      `json:"id" gorm:"primary_key"`
+		UserID      uint      `json:"user_id" gorm:"not null;index"`
+		User        User      `json:"-"`
+		OldRole     string    `json:"old_role"`
+		NewRole     string    `json:"new_role" gorm:"not null"`
+		ChangedByID *uint     `json:"changed_by_id"`
+		ChangedBy   *User     `json:"-"`
+		Source      string    `json:"source" gorm:"not null"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+)
+
+// ChangeRole sets the platform role of u and records who changed it, in the
+// role history and in the audit log with meta.
+func ChangeRole(db *gorm.DB, u *User, role string, changed_by *uint, source string, meta AuditMeta) (RoleChange, error) {
+	if !IsPlatformRole(role) {
+		return RoleChange{}, ErrInvalidPlatformRole
+	}
+	change := RoleChange{UserID: u.ID, OldRole: u.Role, NewRole: role, ChangedByID: changed_by, Source: source}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(u).Update("role", role).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, meta, AuditEvent{
+			Action:     AuditUserRoleChange,
+			TargetType: AuditTargetUser,
+			TargetID:   AuditID(u.ID),
+			Before:     map[string]string{"role": change.OldRole},
+			After:      map[string]string{"role": role},
+			Details:    map[string]string{"source": source},
+		})
+	})
+	if err != nil {
+		return RoleChange{}, err
+	}
+	return change, nil
+}
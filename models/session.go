@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"final-project/utils/token"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval limits how often LastSeenAt is written.
+const sessionTouchInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type (
+	// Session is one login of a user. Its refresh tokens share FamilyID and
+	// its access tokens carry the session id, so revoking it ends both.
+	Session struct {
+		ID         uint       `json:"id" gorm:"primary_key"`
+		UserID     uint       `json:"user_id" gorm:"not null;index"`
+		User       User       `json:"-"`
+		FamilyID   string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+		UserAgent  string     `json:"user_agent"`
+		IP         string     `json:"ip" gorm:"size:64"`
+		LastSeenAt time.Time  `json:"last_seen_at"`
+		RevokedAt  *time.Time `json:"-"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+)
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// CreateSession starts a session with a new refresh token family.
+func CreateSession(db *gorm.DB, user_id uint, user_agent string, ip string) (Session, error) {
+	family_id, err := token.GenerateOpaqueToken(16)
+	if err != nil {
+		return Session{}, err
+	}
+	s := Session{
+		UserID:     user_id,
+		FamilyID:   family_id,
+		UserAgent:  truncate(user_agent, 255),
+		IP:         truncate(ip, 64),
+		LastSeenAt: time.Now(),
+	}
+	if err := db.Create(&s).Error; err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+// SessionForFamily returns the session of a refresh token family. Families
+// issued before sessions existed get one on first use.
+func SessionForFamily(db *gorm.DB, rt RefreshToken, user_agent string, ip string) (Session, error) {
+	s := Session{}
+	err := db.Where("family_id = ?", rt.FamilyID).Take(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s = Session{UserID: rt.UserID, FamilyID: rt.FamilyID, UserAgent: truncate(user_agent, 255), IP: truncate(ip, 64), LastSeenAt: time.Now()}
+		err = db.Create(&s).Error
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	if s.RevokedAt != nil {
+		return Session{}, ErrRefreshTokenInvalid
+	}
+	return s, nil
+}
+
+// ActiveSession returns the session if it is still active and records that
+// it was seen from ip.
+func ActiveSession(db *gorm.DB, id uint, user_id uint, ip string) (Session, error) {
+	s := Session{}
+	if err := db.Where("id = ? AND user_id = ?", id, user_id).Take(&s).Error; err != nil {
+		return Session{}, token.ErrTokenRevoked
+	}
+	if s.RevokedAt != nil {
+		return Session{}, token.ErrTokenRevoked
+	}
+	now := time.Now()
+	if now.Sub(s.LastSeenAt) > sessionTouchInterval || s.IP != ip {
+		err := db.Model(&Session{}).Where("id = ?", s.ID).Updates(map[string]any{"last_seen_at": now, "ip": truncate(ip, 64)}).Error
+		if err != nil {
+			return Session{}, err
+		}
+		s.LastSeenAt = now
+		s.IP = ip
+	}
+	return s, nil
+}
+
+func ListSessions(db *gorm.DB, user_id uint) ([]Session, error) {
+	sessions := []Session{}
+	err := db.Where("user_id = ? AND revoked_at IS NULL", user_id).Order("last_seen_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession ends one session of the user and its refresh tokens.
+func RevokeSession(db *gorm.DB, user_id uint, id uint) error {
+	s := Session{}
+	if err := db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, user_id).Take(&s).Error; err != nil {
+		return ErrSessionNotFound
+	}
+	return RevokeRefreshFamily(db, s.FamilyID)
+}
+
+// RevokeOtherSessions ends every session of the user except keep, and
+// returns how many were ended.
+func RevokeOtherSessions(db *gorm.DB, user_id uint, keep uint) (int, error) {
+	sessions := []Session{}
+	if err := db.Where("user_id = ? AND revoked_at IS NULL AND id <> ?", user_id, keep).Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range sessions {
+			if err := RevokeRefreshFamily(tx, s.FamilyID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
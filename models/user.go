@@ -1,7 +1,8 @@
 package models
 
 import (
-	"final-project/utils/token"
+	"errors"
+	"final-project/utils/password"
 	"html"
 	"strings"
 	"time"
@@ -10,19 +11,46 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrUsernameEmpty = errors.New("username can not be empty")
+	ErrUsernameTaken = errors.New("username is already taken")
+	ErrEmailTaken    = errors.New("email is already registered")
+)
+
 type (
+	// User is the stored account. Responses use UserResponse, the json tags
+	// here only keep secrets out of anything that still marshals a User.
 	User struct {
-		ID           uint           `json:"id" gorm:"primary_key"`
-		Username     string         `gorm:"not null;unique" json:"username"`
-		Email        string         `json:"email" gorm:"not null;unique"`
-		Password     string         `json:"password"`
-		Role         string         `json:"role"`
-		ResetCode    string         `json:"reset_code"`
-		ResetTime    time.Time      `json:"reset_time"`
-		CreatedAt    time.Time      `json:"created_at"`
-		UpdatedAt    time.Time      `json:"updated_at"`
-		Review       []Review       `json:"-"`
-		OrderHistory []OrderHistory `json:"-"`
+		ID       uint   `json:"id" gorm:"primary_key"`
+		Username string `gorm:"not null;unique" json:"username"`
+		Email    string `json:"email" gorm:"not null;unique"`
+		Password string `json:"-"`
+		Role     string `json:"role"`
+		// EmailVerifiedAt is nil until the user opens the verification link.
+		EmailVerifiedAt    *time.Time `json:"email_verified_at"`
+		VerificationSentAt *time.Time `json:"-"`
+		// MFASecret is the active TOTP secret, MFAPendingSecret the one being enrolled.
+		MFAEnabled       bool   `json:"mfa_enabled"`
+		MFASecret        string `json:"-"`
+		MFAPendingSecret string `json:"-"`
+		MFALastStep      int64  `json:"-"`
+		// FailedLoginCount and LockedUntil implement the login lockout, see lockout.go.
+		FailedLoginCount int        `json:"-" gorm:"not null;default:0"`
+		LockedUntil      *time.Time `json:"-"`
+		// DisabledAt blocks the account, PasswordResetRequired refuses logins until
+		// the password is reset. Both are set by admins, see userAdmin.go.
+		DisabledAt            *time.Time `json:"-"`
+		PasswordResetRequired bool       `json:"-" gorm:"not null;default:false"`
+		// DeletionScheduledAt is when a requested deletion anonymizes the
+		// account, AnonymizedAt when it happened, see accountDeletion.go.
+		DeletionScheduledAt *time.Time `json:"-" gorm:"index"`
+		AnonymizedAt        *time.Time `json:"-"`
+		// TokensRevokedBefore invalidates every access token issued before it.
+		TokensRevokedBefore time.Time      `json:"-"`
+		CreatedAt           time.Time      `json:"created_at"`
+		UpdatedAt           time.Time      `json:"updated_at"`
+		Review              []Review       `json:"-"`
+		OrderHistory        []OrderHistory `json:"-"`
 	}
 )
 
@@ -30,17 +58,36 @@ func VerifyPassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-func CheckAdmin(id string, db *gorm.DB) User {
-	var err error
-	u := User{}
-	err = db.Model(User{}).Where("id = ?", id).Take(&u).Error
+func HashPassword(pw string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(pw), password.Cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+// UpdatePassword checks the new password against the policy, stores it,
+// clears a forced reset and revokes every token issued with the old one.
+func (u *User) UpdatePassword(db *gorm.DB, pw string) error {
+	if err := password.PolicyFromEnv().Check(pw, u.Username, u.Email); err != nil {
+		return err
+	}
+	hashedPassword, err := HashPassword(pw)
 	if err != nil {
-		return User{}
+		return err
+	}
+	u.Password = hashedPassword
+	u.PasswordResetRequired = false
+	if err := db.Model(u).Updates(map[string]any{"password": u.Password, "password_reset_required": false}).Error; err != nil {
+		return err
 	}
-	return u
+	return u.RevokeTokens(db)
 }
 
-func LoginCheck(email string, password string, db *gorm.DB) (string, string, uint, error) {
+// LoginCheck returns the user when email and password match. Failed attempts
+// count toward the account lockout. Issuing tokens is left to the caller
+// because accounts with 2FA need a second step.
+func LoginCheck(email string, pw string, db *gorm.DB) (User, error) {
 	var err error
 
 	u := User{}
@@ -48,33 +95,139 @@ func LoginCheck(email string, password string, db *gorm.DB) (string, string, uin
 	err = db.Model(User{}).Where("email = ?", email).Take(&u).Error
 
 	if err != nil {
-		return "", "", 0, err
+		return User{}, err
 	}
 
-	err = VerifyPassword(password, u.Password)
-
-	if err != nil && err == bcrypt.ErrMismatchedHashAndPassword {
-		return "", "", 0, err
+	if err = u.CheckLocked(); err != nil {
+		return User{}, err
 	}
 
-	token, err := token.GenerateToken(u.ID)
+	err = VerifyPassword(pw, u.Password)
 
 	if err != nil {
-		return "", "", 0, err
+		if errFailure := u.RegisterLoginFailure(db); errFailure != nil {
+			return User{}, errFailure
+		}
+		return User{}, err
+	}
+
+	if err = u.CheckCanLogin(); err != nil {
+		return User{}, err
+	}
+
+	if password.NeedsRehash(u.Password) {
+		// the cost was raised since this hash was made, upgrade it while we know the password
+		if hashedPassword, errHash := HashPassword(pw); errHash == nil {
+			db.Model(&u).UpdateColumn("password", hashedPassword)
+			u.Password = hashedPassword
+		}
+	}
+
+	// with 2FA the login is only complete after /login/mfa
+	if !u.MFAEnabled {
+		if err = u.ResetLoginFailures(db); err != nil {
+			return User{}, err
+		}
+	}
+
+	return u, nil
+}
+
+// TokenRevoked reports whether a token issued at iat predates the user's last
+// revocation. iat only has second precision, so a token from the same second
+// is kept: it may be the login right after a password change. Tokens issued
+// earlier in that second lose their session to RevokeUserRefreshTokens.
+func (u *User) TokenRevoked(iat time.Time) bool {
+	if u.TokensRevokedBefore.IsZero() {
+		return false
+	}
+	return iat.Before(u.TokensRevokedBefore.Truncate(time.Second))
+}
+
+// RevokeTokens invalidates every access and refresh token the user holds.
+func (u *User) RevokeTokens(db *gorm.DB) error {
+	u.TokensRevokedBefore = time.Now()
+	if err := db.Model(u).Update("tokens_revoked_before", u.TokensRevokedBefore).Error; err != nil {
+		return err
+	}
+	return RevokeUserRefreshTokens(db, u.ID)
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) MarkEmailVerified(db *gorm.DB) error {
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	return db.Model(u).Update("email_verified_at", now).Error
+}
+
+func (u *User) MarkVerificationSent(db *gorm.DB) error {
+	now := time.Now()
+	u.VerificationSentAt = &now
+	return db.Model(u).Update("verification_sent_at", now).Error
+}
+
+// UpdateProfile changes the username and email when they are not nil. A new
+// email must be verified again.
+func (u *User) UpdateProfile(db *gorm.DB, username *string, email *string) (bool, error) {
+	updates := map[string]any{}
+	if username != nil {
+		clean := html.EscapeString(strings.TrimSpace(*username))
+		if clean == "" {
+			return false, ErrUsernameEmpty
+		}
+		if clean != u.Username {
+			var count int64
+			if err := db.Model(User{}).Where("username = ? AND id <> ?", clean, u.ID).Count(&count).Error; err != nil {
+				return false, err
+			}
+			if count > 0 {
+				return false, ErrUsernameTaken
+			}
+			updates["username"] = clean
+		}
+	}
+	emailChanged := false
+	if email != nil && strings.TrimSpace(*email) != u.Email {
+		clean := strings.TrimSpace(*email)
+		var count int64
+		if err := db.Model(User{}).Where("email = ? AND id <> ?", clean, u.ID).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, ErrEmailTaken
+		}
+		updates["email"] = clean
+		updates["email_verified_at"] = nil
+		updates["verification_sent_at"] = nil
+		emailChanged = true
 	}
-	return token, u.Username, u.ID, nil
+	if len(updates) == 0 {
+		return false, nil
+	}
+	if err := db.Model(u).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	if err := db.Model(User{}).Where("id = ?", u.ID).Take(u).Error; err != nil {
+		return false, err
+	}
+	return emailChanged, nil
 }
 
 func (u *User) SaveUser(db *gorm.DB) (*User, error) {
+	if err := password.PolicyFromEnv().Check(u.Password, u.Username, u.Email); err != nil {
+		return &User{}, err
+	}
 	//turn password into hash
-	hashedPassword, errPassword := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	hashedPassword, errPassword := HashPassword(u.Password)
 	if errPassword != nil {
 		return &User{}, errPassword
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	//remove spaces in username
 	u.Username = html.EscapeString(strings.TrimSpace(u.Username))
-	u.ResetTime = time.Now()
 
 	var err error = db.Create(&u).Error
 	if err != nil {
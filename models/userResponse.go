@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type (
+	// UserResponse is the only shape a user is sent to clients in. It is
+	// built field by field so new columns on User stay private by default.
+	UserResponse struct {
+		ID              uint       `json:"id"`
+		Username        string     `json:"username"`
+		Email           string     `json:"email"`
+		Role            string     `json:"role"`
+		EmailVerified   bool       `json:"email_verified"`
+		EmailVerifiedAt *time.Time `json:"email_verified_at"`
+		MFAEnabled      bool       `json:"mfa_enabled"`
+		// DeletionScheduledAt is set while a requested deletion can be cancelled
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+		CreatedAt           time.Time  `json:"created_at"`
+		UpdatedAt           time.Time  `json:"updated_at"`
+	}
+
+	// AdminUserResponse adds the account state admins manage.
+	AdminUserResponse struct {
+		UserResponse
+		Disabled              bool       `json:"disabled"`
+		DisabledAt            *time.Time `json:"disabled_at"`
+		PasswordResetRequired bool       `json:"password_reset_required"`
+		LockedUntil           *time.Time `json:"locked_until"`
+		FailedLoginCount      int        `json:"failed_login_count"`
+	}
+)
+
+func NewUserResponse(u User) UserResponse {
+	return UserResponse{
+		ID:                  u.ID,
+		Username:            u.Username,
+		Email:               u.Email,
+		Role:                u.Role,
+		EmailVerified:       u.EmailVerified(),
+		EmailVerifiedAt:     u.EmailVerifiedAt,
+		MFAEnabled:          u.MFAEnabled,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
+	}
+}
+
+func NewAdminUserResponse(u User) AdminUserResponse {
+	return AdminUserResponse{
+		UserResponse:          NewUserResponse(u),
+		Disabled:              u.Disabled(),
+		DisabledAt:            u.DisabledAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+		LockedUntil:           u.LockedUntil,
+		FailedLoginCount:      u.FailedLoginCount,
+	}
+}
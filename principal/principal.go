@@ -0,0 +1,61 @@
+// Package principal stores and reads the authenticated caller of a request.
+// Every getter is safe to call on routes without the auth middleware and
+// reports false instead of panicking.
+package principal
+
+import (
+	"final-project/models"
+	"final-project/utils/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextKey = "common_request"
+
+func Set(c *gin.Context, p models.CommonRequest) {
+	c.Set(contextKey, p)
+}
+
+func Get(c *gin.Context) (models.CommonRequest, bool) {
+	v, ok := c.Get(contextKey)
+	if !ok {
+		return models.CommonRequest{}, false
+	}
+	p, ok := v.(models.CommonRequest)
+	return p, ok
+}
+
+func User(c *gin.Context) (models.User, bool) {
+	p, ok := Get(c)
+	if !ok {
+		return models.User{}, false
+	}
+	return p.User, true
+}
+
+func UserID(c *gin.Context) (uint, bool) {
+	u, ok := User(c)
+	return u.ID, ok
+}
+
+func Claims(c *gin.Context) (*token.Claims, bool) {
+	p, ok := Get(c)
+	if !ok || p.Claims == nil {
+		return nil, false
+	}
+	return p.Claims, true
+}
+
+// APIKey returns the key the request authenticated with, if any.
+func APIKey(c *gin.Context) (*models.APIKey, bool) {
+	p, ok := Get(c)
+	if !ok || p.APIKey == nil {
+		return nil, false
+	}
+	return p.APIKey, true
+}
+
+func IsAdmin(c *gin.Context) bool {
+	p, ok := Get(c)
+	return ok && p.IsAdmin
+}
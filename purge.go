@@ -0,0 +1,37 @@
+package main
+
+import (
+	"final-project/models"
+	"final-project/utils"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// purgeDeletedAccounts anonymizes the accounts whose deletion grace period is
+// over. It runs as `purge-deleted-accounts` from cron, or in the server every
+// ACCOUNT_PURGE_INTERVAL_MINUTES when that is above zero.
+func purgeDeletedAccounts(db *gorm.DB) error {
+	n, err := models.PurgeDueDeletions(db)
+	if n > 0 {
+		log.Printf("anonymized %d deleted accounts\n", n)
+	}
+	return err
+}
+
+func startAccountPurger(db *gorm.DB) {
+	minutes, err := strconv.Atoi(utils.Getenv("ACCOUNT_PURGE_INTERVAL_MINUTES", "60"))
+	if err != nil || minutes <= 0 {
+		return
+	}
+	go func() {
+		for {
+			if err := purgeDeletedAccounts(db); err != nil {
+				log.Println("purge deleted accounts:", err)
+			}
+			time.Sleep(time.Minute * time.Duration(minutes))
+		}
+	}()
+}
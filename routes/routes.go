@@ -3,10 +3,16 @@ package routes
 import (
 	"final-project/controllers"
 	"final-project/middlewares"
+	"final-project/models"
 	"final-project/utils"
+	"final-project/utils/mailer"
+	"final-project/utils/oidc"
+	"final-project/utils/ratelimit"
+	"final-project/utils/token"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,15 +23,42 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
 )
 
-func SetupRouter(db *gorm.DB) *gin.Engine {
+// trustedProxies reads the comma separated TRUSTED_PROXIES, none are trusted by default.
+func trustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(utils.Getenv("TRUSTED_PROXIES", ""), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+func SetupRouter(db *gorm.DB, mail mailer.Mailer) *gin.Engine {
 	r := gin.Default()
-	// set db to gin context
+	// X-Forwarded-For is only honoured from these proxies, ClientIP is the peer address otherwise
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		panic(err.Error())
+	}
+	// set db and mailer to gin context
+	providers := oidc.ProvidersFromEnv()
 	r.Use(func(c *gin.Context) {
 		c.Set("db", db)
+		c.Set("mailer", mail)
+		c.Set("oidc", providers)
 	})
 
 	// gin.SetMode(gin.ReleaseMode)
 
+	if utils.Getenv("REVOCATION_STORE", "database") == "memory" {
+		token.SetRevocationStore(token.NewMemoryRevocationStore())
+	} else {
+		token.SetRevocationStore(models.NewGormRevocationStore(db))
+	}
+
+	// the export streams every matching entry, registered before the handler timeout so it is not cut off
+	r.GET("/admin/audit_logs/export", middlewares.JwtAuthMiddleware(db), middlewares.RequirePermission(models.PermAuditRead, nil), controllers.ExportAuditLogs)
+
 	timeoutval, _ := strconv.Atoi(utils.Getenv("HANDLER_TIMEOUT", "5"))
 
 	r.Use(timeout.Timeout(
@@ -36,24 +69,77 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 			fmt.Println("timeout happen, url:", r.URL.String())
 		})))
 
-	r.POST("/register", controllers.Register)
-	r.POST("/login", controllers.Login)
-	r.POST("/get_reset_link", controllers.GetResetLink)
-	r.POST("/reset_password/:token", controllers.ResetPassword)
+	// counters live in this process, replace the store with a shared one when running several instances
+	limiter := ratelimit.NewMemoryStore()
+	perIP := middlewares.RateLimit(limiter, "auth-ip", 20, time.Minute, middlewares.ByIP)
+	perAccount := middlewares.RateLimit(limiter, "login-account", 10, time.Minute, middlewares.ByJSONField("email"))
+	resetPerAccount := middlewares.RateLimit(limiter, "reset-account", 3, time.Hour, middlewares.ByJSONField("email"))
+
+	r.POST("/register", perIP, controllers.Register)
+	r.POST("/login", perIP, perAccount, controllers.Login)
+	r.POST("/login/mfa", perIP, controllers.LoginMFA)
+	r.POST("/token/refresh", controllers.RefreshToken)
+	r.POST("/logout", controllers.Logout)
+	r.POST("/get_reset_link", perIP, resetPerAccount, controllers.GetResetLink)
+	r.POST("/reset_password/:token", perIP, controllers.ResetPassword)
+	r.GET("/verify_email/:token", controllers.VerifyEmail)
+	r.GET("/oauth/:provider/login", perIP, controllers.OAuthLogin)
+	r.GET("/oauth/:provider/callback", perIP, controllers.OAuthCallback)
 
 	MiddlewareRoute := r.Group("/restaurant")
 	MiddlewareRoute.Use(middlewares.JwtAuthMiddleware(db))
-	MiddlewareRoute.POST("/create", controllers.CreateRestaurant)
-	MiddlewareRoute.POST("/create/menus", controllers.CreateMenus)
-	MiddlewareRoute.DELETE("/delete/menus/:id", controllers.DeleteMenus)
+	MiddlewareRoute.POST("/create", middlewares.RequirePermission(models.PermRestaurantCreate, nil), controllers.CreateRestaurant)
+	MiddlewareRoute.POST("/create/menus", middlewares.RequirePermission(models.PermMenuWrite, middlewares.FromJSON("restaurant_id")), controllers.CreateMenus)
+	MiddlewareRoute.DELETE("/delete/menus/:id", middlewares.RequirePermission(models.PermMenuWrite, middlewares.FromQuery("restoId")), controllers.DeleteMenus)
+	MiddlewareRoute.PUT("/:id/opening_hours", middlewares.RequirePermission(models.PermRestaurantWrite, middlewares.FromParam("id")), controllers.SetOpeningHours)
+	MiddlewareRoute.POST("/:id/members", middlewares.RequirePermission(models.PermRestaurantMembers, middlewares.FromParam("id")), controllers.AddRestaurantMember)
+	MiddlewareRoute.DELETE("/:id/members/:user_id", middlewares.RequirePermission(models.PermRestaurantMembers, middlewares.FromParam("id")), controllers.RemoveRestaurantMember)
+
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
 
 	r.GET("/get_all_resto", controllers.GetAllRestaurant)
+	r.GET("/restaurants", controllers.GetAllRestaurant)
+	r.GET("/restaurants/nearby", controllers.NearbyRestaurants)
+	r.GET("/restaurants/:id", controllers.GetRestaurant)
+
+	RestaurantsMiddlewareRoute := r.Group("/restaurants")
+	RestaurantsMiddlewareRoute.Use(middlewares.JwtAuthMiddleware(db))
+	RestaurantsMiddlewareRoute.PATCH("/:id", middlewares.RequirePermission(models.PermRestaurantWrite, middlewares.FromParam("id")), controllers.UpdateRestaurant)
+	RestaurantsMiddlewareRoute.DELETE("/:id", middlewares.RequirePermission(models.PermRestaurantWrite, middlewares.FromParam("id")), controllers.DeleteRestaurant)
 
 	UserMiddlewareRoute := r.Group("/user")
 	UserMiddlewareRoute.Use(middlewares.JwtAuthMiddleware(db))
-	UserMiddlewareRoute.POST("/create/orders", controllers.CreateOrder)
-	UserMiddlewareRoute.GET("/show/order/:id/restaurant", controllers.ShowOrderByResto)
-	UserMiddlewareRoute.POST("/send_review", controllers.CreateReview)
+	UserMiddlewareRoute.POST("/create/orders", middlewares.RequirePermission(models.PermOrderCreate, nil), middlewares.RequireVerifiedEmail(), controllers.CreateOrder)
+	UserMiddlewareRoute.GET("/show/order/:id/restaurant", middlewares.RequirePermission(models.PermOrderRead, middlewares.FromParam("id")), controllers.ShowOrderByResto)
+	UserMiddlewareRoute.POST("/send_review", middlewares.RequirePermission(models.PermReviewCreate, nil), middlewares.RequireVerifiedEmail(), controllers.CreateReview)
+	UserMiddlewareRoute.GET("/me", middlewares.RequirePermission(models.PermAccountRead, nil), controllers.GetProfile)
+	UserMiddlewareRoute.PATCH("/me", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.UpdateProfile)
+	UserMiddlewareRoute.GET("/me/export", middlewares.RequirePermission(models.PermAccountRead, nil), controllers.ExportAccount)
+	UserMiddlewareRoute.POST("/me/deletion", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.RequestAccountDeletion)
+	UserMiddlewareRoute.DELETE("/me/deletion", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.CancelAccountDeletion)
+	UserMiddlewareRoute.POST("/change_password", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.ChangePassword)
+	UserMiddlewareRoute.POST("/resend_verification", middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.ResendVerification)
+	UserMiddlewareRoute.POST("/mfa/enroll", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.EnrollMFA)
+	UserMiddlewareRoute.POST("/mfa/verify", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.VerifyMFAEnrollment)
+	UserMiddlewareRoute.POST("/mfa/disable", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.DisableMFA)
+	UserMiddlewareRoute.GET("/sessions", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountRead, nil), controllers.ListSessions)
+	UserMiddlewareRoute.DELETE("/sessions", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.RevokeOtherSessions)
+	UserMiddlewareRoute.DELETE("/sessions/:id", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.RevokeSession)
+	UserMiddlewareRoute.POST("/api_keys", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.CreateAPIKey)
+	UserMiddlewareRoute.GET("/api_keys", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountRead, nil), controllers.ListAPIKeys)
+	UserMiddlewareRoute.DELETE("/api_keys/:id", middlewares.RequireSession(), middlewares.RequirePermission(models.PermAccountWrite, nil), controllers.RevokeAPIKey)
+
+	AdminMiddlewareRoute := r.Group("/admin")
+	AdminMiddlewareRoute.Use(middlewares.JwtAuthMiddleware(db))
+	AdminMiddlewareRoute.GET("/users", middlewares.RequirePermission(models.PermUserManage, nil), controllers.ListUsers)
+	AdminMiddlewareRoute.GET("/users/:id", middlewares.RequirePermission(models.PermUserManage, nil), controllers.GetUser)
+	AdminMiddlewareRoute.POST("/users/:id/disable", middlewares.RequirePermission(models.PermUserManage, nil), controllers.DisableUser)
+	AdminMiddlewareRoute.POST("/users/:id/enable", middlewares.RequirePermission(models.PermUserManage, nil), controllers.EnableUser)
+	AdminMiddlewareRoute.POST("/users/:id/force_password_reset", middlewares.RequirePermission(models.PermUserManage, nil), controllers.ForcePasswordReset)
+	AdminMiddlewareRoute.POST("/users/:id/reset_mfa", middlewares.RequirePermission(models.PermUserManage, nil), controllers.ResetUserMFA)
+	AdminMiddlewareRoute.PUT("/users/:id/role", middlewares.RequirePermission(models.PermUserRoles, nil), controllers.ChangeUserRole)
+	AdminMiddlewareRoute.GET("/audit_logs", middlewares.RequirePermission(models.PermAuditRead, nil), controllers.ListAuditLogs)
+	AdminMiddlewareRoute.POST("/invitations", middlewares.RequirePermission(models.PermUserRoles, nil), controllers.CreateInvitation)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"encoding/csv"
+	"final-project/models"
+	"final-project/utils/mailer"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRouter serves SetupRouter over an empty in-memory sqlite database.
+func newTestRouter(t *testing.T) (*gin.Engine, *gorm.DB, *mailer.MemoryMailer) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := models.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	mail := mailer.NewMemoryMailer()
+	return SetupRouter(db, mail), db, mail
+}
+
+// serve sends a JSON request from the peer 192.0.2.1 with headers set.
+func serve(r *gin.Engine, method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// loginFromSpoofedIPs makes attempts logins, each for another email and with
+// another X-Forwarded-For, and returns the status of the last one.
+func loginFromSpoofedIPs(r *gin.Engine, attempts int) int {
+	status := 0
+	for i := 0; i < attempts; i++ {
+		body := fmt.Sprintf(`{"email":"user%d@example.com","password":"wrong-password"}`, i)
+		w := serve(r, http.MethodPost, "/login", body, map[string]string{"X-Forwarded-For": fmt.Sprintf("203.0.113.%d", i)})
+		status = w.Code
+	}
+	return status
+}
+
+func TestLoginLimiterIgnoresSpoofedForwardedFor(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "")
+	r, _, _ := newTestRouter(t)
+	// auth-ip allows 20 attempts a minute
+	if status := loginFromSpoofedIPs(r, 21); status != http.StatusTooManyRequests {
+		t.Fatalf("21st login from one peer: status %d, want %d", status, http.StatusTooManyRequests)
+	}
+}
+
+func TestLoginLimiterHonoursTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.1")
+	r, _, _ := newTestRouter(t)
+	if status := loginFromSpoofedIPs(r, 21); status == http.StatusTooManyRequests {
+		t.Fatal("logins forwarded by a trusted proxy for different clients were limited together")
+	}
+}
+
+// createTestUser stores a customer with a verified email.
+func createTestUser(t *testing.T, db *gorm.DB, email string) models.User {
+	t.Helper()
+	now := time.Now()
+	u := models.User{Username: strings.Split(email, "@")[0], Email: email, Password: "not-a-hash", Role: models.RoleCustomer, EmailVerifiedAt: &now}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestAPIKeyRejectedWhilePasswordResetRequired(t *testing.T) {
+	r, db, _ := newTestRouter(t)
+	u := createTestUser(t, db, "ana@example.com")
+	key, _, err := models.CreateAPIKey(db, u.ID, "script", []string{models.PermAccountRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := map[string]string{"Authorization": "ApiKey " + key}
+	if w := serve(r, http.MethodGet, "/user/me", "", auth); w.Code != http.StatusOK {
+		t.Fatalf("GET /user/me with a key: status %d, body %s", w.Code, w.Body)
+	}
+
+	if err := u.RequirePasswordReset(db, models.AuditMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(r, http.MethodGet, "/user/me", "", auth); w.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /user/me with a key after a forced reset: status %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestFailedLoginAuditKeepsNoEmail(t *testing.T) {
+	r, db, _ := newTestRouter(t)
+	serve(r, http.MethodPost, "/login", `{"email":"ana@example.com","password":"wrong-password"}`, nil)
+
+	entries := []models.AuditLog{}
+	if err := db.Where("action = ?", models.AuditLoginFailed).Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d failed login entries, want 1", len(entries))
+	}
+	if strings.Contains(entries[0].Details, "ana@example.com") || !strings.Contains(entries[0].Details, models.AuditEmail("Ana@example.com ")) {
+		t.Fatalf("details %s keep the email or miss its hash", entries[0].Details)
+	}
+}
+
+func TestExportAuditLogs(t *testing.T) {
+	r, db, _ := newTestRouter(t)
+	admin := createTestUser(t, db, "admin@example.com")
+	if err := db.Model(&admin).Updates(map[string]any{"role": models.RoleAdmin, "mfa_enabled": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	key, _, err := models.CreateAPIKey(db, admin.ID, "export", []string{models.PermAuditRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// more than one batch
+	for i := 0; i < 600; i++ {
+		event := models.AuditEvent{Action: models.AuditLoginFailed, TargetType: models.AuditTargetUser, Details: map[string]int{"n": i}}
+		if err := models.RecordAudit(db, models.AuditMeta{}, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := serve(r, http.MethodGet, "/admin/audit_logs/export?action="+models.AuditLoginFailed, "", map[string]string{"Authorization": "ApiKey " + key})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 601 {
+		t.Fatalf("%d rows, want a header and 600 entries", len(rows))
+	}
+	if rows[600][10] != `{"n":599}` {
+		t.Fatalf("last row details %s", rows[600][10])
+	}
+}
+
+// waitForMail returns the last message with subject sent to address, mails
+// go out in the background.
+func waitForMail(t *testing.T, mail *mailer.MemoryMailer, address string, subject string) mailer.Message {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if msg, ok := mail.Last(address); ok && msg.Subject == subject {
+			return msg
+		}
+	}
+	t.Fatalf("no %q mail to %s", subject, address)
+	return mailer.Message{}
+}
+
+func TestResetLinkMail(t *testing.T) {
+	t.Setenv("RESET_PASSWORD_URL", "https://app.example.com/reset?lang=id")
+	r, _, mail := newTestRouter(t)
+	w := serve(r, http.MethodPost, "/register", `{"username":"Tom & Jerry","email":"tom@example.com","password":"a-long-enough-password"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("register: status %d, body %s", w.Code, w.Body)
+	}
+
+	serve(r, http.MethodPost, "/get_reset_link", `{"email":"tom@example.com"}`, nil)
+	msg := waitForMail(t, mail, "tom@example.com", "Reset your password")
+	if !strings.Contains(msg.HTML, "https://app.example.com/reset?lang=id&amp;token=") {
+		t.Fatalf("reset mail does not link to the frontend page:\n%s", msg.HTML)
+	}
+	if !strings.Contains(msg.HTML, "Tom &amp; Jerry") || !strings.Contains(msg.Text, "Tom & Jerry") {
+		t.Fatalf("username escaped wrong:\n%s\n%s", msg.HTML, msg.Text)
+	}
+}
+
+func TestLockedLoginLooksLikeUnknownEmail(t *testing.T) {
+	r, db, _ := newTestRouter(t)
+	u := createTestUser(t, db, "ana@example.com")
+	if err := db.Model(&u).Update("locked_until", time.Now().Add(time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	locked := serve(r, http.MethodPost, "/login", `{"email":"ana@example.com","password":"wrong-password"}`, nil)
+	unknown := serve(r, http.MethodPost, "/login", `{"email":"nobody@example.com","password":"wrong-password"}`, nil)
+	if locked.Code != unknown.Code || locked.Body.String() != unknown.Body.String() || locked.Header().Get("Retry-After") != "" {
+		t.Fatalf("locked account: %d %s, unknown email: %d %s", locked.Code, locked.Body, unknown.Code, unknown.Body)
+	}
+}
@@ -2,8 +2,8 @@ package utils
 
 import (
 	"encoding/base64"
-	"math/rand"
 	"os"
+	"strings"
 )
 
 func Getenv(key, fallback string) string {
@@ -13,6 +13,13 @@ func Getenv(key, fallback string) string {
 	return fallback
 }
 
+// PublicURL joins path to PUBLIC_BASE_URL, the address users reach the
+// service on. Links in emails must never be built from the request Host.
+func PublicURL(path string) string {
+	base := strings.TrimRight(Getenv("PUBLIC_BASE_URL", "http://localhost:8080"), "/")
+	return base + "/" + strings.TrimLeft(path, "/")
+}
+
 func Encode(s string) string {
 	data := base64.StdEncoding.EncodeToString([]byte(s))
 	return string(data)
@@ -26,12 +33,3 @@ func Decode(s string) (string, error) {
 
 	return string(data), nil
 }
-
-func GetToken(n int) string {
-	var charset = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(b)
-}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes every message to a file, or stdout, instead of sending it.
+// It is meant for local development where reset links are read from the log.
+type LogMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewLogMailer(path string, from string) (*LogMailer, error) {
+	if path == "" {
+		return &LogMailer{w: os.Stdout, from: from}, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &LogMailer{w: f, from: from}, nil
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "---- mail %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n----\n",
+		time.Now().Format(time.RFC3339), m.from, msg.To, msg.Subject, msg.Text)
+	return err
+}
@@ -0,0 +1,45 @@
+// Package mailer sends transactional emails. The implementation is picked
+// from the environment by FromEnv:
+//
+//	MAILER         smtp, log or memory (default log)
+//	MAIL_FROM      sender address
+//	MAIL_LOG_FILE  file the log mailer appends to, stdout when empty
+//	SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD
+package mailer
+
+import (
+	"final-project/utils"
+	"fmt"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+func FromEnv() (Mailer, error) {
+	from := utils.Getenv("MAIL_FROM", "no-reply@localhost")
+
+	switch driver := utils.Getenv("MAILER", "log"); driver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     utils.Getenv("SMTP_HOST", "localhost"),
+			Port:     utils.Getenv("SMTP_PORT", "587"),
+			Username: utils.Getenv("SMTP_USERNAME", ""),
+			Password: utils.Getenv("SMTP_PASSWORD", ""),
+			From:     from,
+		}, nil
+	case "log":
+		return NewLogMailer(utils.Getenv("MAIL_LOG_FILE", ""), from)
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", driver)
+	}
+}
@@ -0,0 +1,17 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"final-project/utils/token"
+)
+
+// NewPKCE returns a code verifier and its S256 challenge (RFC 7636).
+func NewPKCE() (string, string, error) {
+	verifier, err := token.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
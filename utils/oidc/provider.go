@@ -0,0 +1,268 @@
+// Package oidc implements the OpenID Connect authorization code flow with
+// PKCE against any provider that publishes a discovery document. Providers
+// are configured from the environment:
+//
+//	OIDC_PROVIDERS                 comma separated provider names, e.g. google,keycloak
+//	OIDC_<NAME>_ISSUER             issuer URL, discovery is read from <issuer>/.well-known/openid-configuration
+//	OIDC_<NAME>_CLIENT_ID          client id registered at the provider
+//	OIDC_<NAME>_CLIENT_SECRET      client secret, empty for public clients
+//	OIDC_<NAME>_REDIRECT_URL       callback URL, defaults to PUBLIC_BASE_URL/oauth/<name>/callback
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"final-project/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrUnknownProvider = errors.New("unknown login provider")
+
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Identity is what we keep from a verified ID token.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+func ProvidersFromEnv() map[string]*Provider {
+	providers := map[string]*Provider{}
+	for _, name := range strings.Split(utils.Getenv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers[name] = &Provider{
+			Name:         name,
+			Issuer:       strings.TrimRight(utils.Getenv(prefix+"ISSUER", ""), "/"),
+			ClientID:     utils.Getenv(prefix+"CLIENT_ID", ""),
+			ClientSecret: utils.Getenv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  utils.Getenv(prefix+"REDIRECT_URL", utils.PublicURL("/oauth/"+name+"/callback")),
+			Scopes:       []string{"openid", "email", "profile"},
+		}
+	}
+	return providers
+}
+
+func (p *Provider) client() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return &http.Client{Timeout: 5 * time.Second}
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	res, err := p.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d", u, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (p *Provider) metadata(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	d := &discovery{}
+	if err := p.getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", d); err != nil {
+		return nil, err
+	}
+	if strings.TrimRight(d.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", d.Issuer, p.Issuer)
+	}
+	p.discovery = d
+	return d, nil
+}
+
+// AuthCodeURL returns the provider URL the browser is sent to.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, challenge string) (string, error) {
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", strings.Join(p.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems the authorization code and returns the identity from the
+// verified ID token. nonce must be the one sent with AuthCodeURL.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (Identity, error) {
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("code_verifier", verifier)
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client().Do(req)
+	if err != nil {
+		return Identity{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return Identity{}, fmt.Errorf("token endpoint returned status %d", res.StatusCode)
+	}
+
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return Identity{}, err
+	}
+	if body.IDToken == "" {
+		return Identity{}, errors.New("token response has no id_token")
+	}
+	return p.verifyIDToken(ctx, body.IDToken, nonce)
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, raw string, nonce string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			return nil, errors.New("HMAC signed ID tokens are not accepted")
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimRight(iss, "/") != p.Issuer {
+		return Identity{}, errors.New("ID token has an unexpected issuer")
+	}
+	if !claims.VerifyAudience(p.ClientID, true) {
+		return Identity{}, errors.New("ID token has an unexpected audience")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Identity{}, errors.New("ID token is expired")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return Identity{}, errors.New("ID token nonce does not match")
+	}
+
+	identity := Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	if identity.Subject == "" {
+		return Identity{}, errors.New("ID token has no subject")
+	}
+	return identity, nil
+}
+
+// key returns the provider key for kid, refetching the JWKS once when the kid
+// is unknown so provider key rotations are picked up.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	fresh := time.Since(p.keysAt) < time.Minute
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, fmt.Errorf("unknown ID token key %q", kid)
+	}
+
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set jwkSet
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys, err := set.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysAt = time.Now()
+	p.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown ID token key %q", kid)
+	}
+	return key, nil
+}
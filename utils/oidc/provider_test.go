@@ -0,0 +1,232 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testClientID = "food-app"
+	testCode     = "code-from-the-browser"
+	testNonce    = "nonce-sent-with-the-login"
+)
+
+// mockIssuer is a local OpenID Connect provider serving discovery, a JWKS and
+// a token endpoint that answers with an ID token carrying claims.
+type mockIssuer struct {
+	*httptest.Server
+	t         *testing.T
+	key       *rsa.PrivateKey
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{t: t, key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "test-key",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("code") != testCode ||
+			r.PostForm.Get("client_id") != testClientID || base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "unused", "id_token": m.sign(m.claims)})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	m.claims = jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"email":          "ana@example.com",
+		"email_verified": true,
+		"name":           "Ana",
+		"nonce":          testNonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+	return m
+}
+
+func (m *mockIssuer) sign(claims jwt.MapClaims) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = "test-key"
+	signed, err := t.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return signed
+}
+
+func (m *mockIssuer) provider() *Provider {
+	return &Provider{
+		Name:        "mock",
+		Issuer:      m.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/oauth/mock/callback",
+		Scopes:      []string{"openid", "email", "profile"},
+		HTTPClient:  m.Client(),
+	}
+}
+
+// login runs AuthCodeURL and Exchange like the login and callback handlers do.
+func (m *mockIssuer) login(t *testing.T, nonce string) (Identity, error) {
+	t.Helper()
+	p := m.provider()
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(context.Background(), "state", testNonce, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.challenge = u.Query().Get("code_challenge")
+	return p.Exchange(context.Background(), testCode, verifier, nonce)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockIssuer(t)
+	authURL, err := m.provider().AuthCodeURL(context.Background(), "the-state", testNonce, "the-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, m.URL+"/authorize?") {
+		t.Fatalf("auth URL %q does not use the discovered endpoint", authURL)
+	}
+	u, _ := url.Parse(authURL)
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"state":                 "the-state",
+		"nonce":                 testNonce,
+		"code_challenge":        "the-challenge",
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	}
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockIssuer(t)
+	identity, err := m.login(t, testNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Subject: "subject-1", Email: "ana@example.com", EmailVerified: true, Name: "Ana"}
+	if identity != want {
+		t.Fatalf("identity = %+v, want %+v", identity, want)
+	}
+}
+
+func TestExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		nonce  string
+		change func(m *mockIssuer)
+		want   string
+	}{
+		{name: "wrong nonce", nonce: "another-nonce", want: "nonce does not match"},
+		{name: "wrong audience", change: func(m *mockIssuer) { m.claims["aud"] = "another-client" }, want: "unexpected audience"},
+		{name: "wrong issuer", change: func(m *mockIssuer) { m.claims["iss"] = "https://issuer.example.com" }, want: "unexpected issuer"},
+		{name: "expired", change: func(m *mockIssuer) { m.claims["exp"] = time.Now().Add(-time.Minute).Unix() }, want: "expired"},
+		{name: "no expiry", change: func(m *mockIssuer) { delete(m.claims, "exp") }, want: "expired"},
+		{name: "no subject", change: func(m *mockIssuer) { delete(m.claims, "sub") }, want: "no subject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockIssuer(t)
+			if tt.change != nil {
+				tt.change(m)
+			}
+			nonce := testNonce
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			identity, err := m.login(t, nonce)
+			if err == nil {
+				t.Fatalf("accepted %+v", identity)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsHMACSignedIDTokens(t *testing.T) {
+	m := newMockIssuer(t)
+	p := m.provider()
+	// an attacker signing with the public key as an HMAC secret
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, m.claims).SignedString(m.key.PublicKey.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.verifyIDToken(context.Background(), signed, testNonce)
+	if err == nil || !strings.Contains(err.Error(), "HMAC") {
+		t.Fatalf("HMAC signed ID token: error %v", err)
+	}
+}
+
+func TestExchangeRejectsBadPKCEVerifier(t *testing.T) {
+	m := newMockIssuer(t)
+	_, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.challenge = challenge
+	_, err = m.provider().Exchange(context.Background(), testCode, "not-the-verifier", testNonce)
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Fatalf("wrong code verifier: error %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package password holds the password policy applied whenever a password is
+// set. It is configured from the environment:
+//
+//	PASSWORD_MIN_LENGTH    minimum length in characters (default 8)
+//	PASSWORD_CHECK_COMMON  reject passwords from the bundled common list (default true)
+//	BCRYPT_COST            bcrypt cost for new hashes (default bcrypt.DefaultCost)
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"final-project/utils"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt ignores everything after 72 bytes, so longer passwords are refused
+// instead of silently truncated.
+const maxBytes = 72
+
+//go:embed common.txt
+var commonList string
+
+var common = func() map[string]bool {
+	set := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(commonList))
+	for scanner.Scan() {
+		if w := strings.TrimSpace(scanner.Text()); w != "" {
+			set[strings.ToLower(w)] = true
+		}
+	}
+	return set
+}()
+
+type Policy struct {
+	MinLength    int
+	RejectCommon bool
+}
+
+func PolicyFromEnv() Policy {
+	min, err := strconv.Atoi(utils.Getenv("PASSWORD_MIN_LENGTH", "8"))
+	if err != nil || min < 1 {
+		min = 8
+	}
+	return Policy{
+		MinLength:    min,
+		RejectCommon: utils.Getenv("PASSWORD_CHECK_COMMON", "true") != "false",
+	}
+}
+
+// PolicyError lists every rule a password broke, so clients can show them
+// next to the password field.
+type PolicyError struct {
+	Problems []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password " + strings.Join(e.Problems, ", ")
+}
+
+// Check validates pw for the account identified by username and email and
+// returns a *PolicyError, or nil when the password is acceptable.
+func (p Policy) Check(pw string, username string, email string) error {
+	problems := []string{}
+
+	if utf8.RuneCountInString(pw) < p.MinLength {
+		problems = append(problems, "must be at least "+strconv.Itoa(p.MinLength)+" characters")
+	}
+	if len(pw) > maxBytes {
+		problems = append(problems, "must be at most "+strconv.Itoa(maxBytes)+" bytes")
+	}
+
+	lower := strings.ToLower(pw)
+	if username != "" && lower == strings.ToLower(username) {
+		problems = append(problems, "must not be the username")
+	}
+	if email != "" {
+		email = strings.ToLower(email)
+		local, _, _ := strings.Cut(email, "@")
+		if lower == email || lower == local {
+			problems = append(problems, "must not be the email address")
+		}
+	}
+	if p.RejectCommon && common[lower] {
+		problems = append(problems, "is too common")
+	}
+
+	if len(problems) > 0 {
+		return &PolicyError{Problems: problems}
+	}
+	return nil
+}
+
+func Cost() int {
+	cost, err := strconv.Atoi(utils.Getenv("BCRYPT_COST", strconv.Itoa(bcrypt.DefaultCost)))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+// NeedsRehash reports whether hash was made with a lower cost than Cost().
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < Cost()
+}
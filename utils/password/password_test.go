@@ -0,0 +1,59 @@
+package password
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 8, RejectCommon: true}
+	tests := []struct {
+		name     string
+		pw       string
+		problems []string
+	}{
+		{name: "acceptable", pw: "correct horse battery"},
+		{name: "too short", pw: "k9#vQ", problems: []string{"must be at least 8 characters"}},
+		{name: "length counts characters", pw: "pässwörd-ü"},
+		{name: "too long for bcrypt", pw: strings.Repeat("x", 73), problems: []string{"must be at most 72 bytes"}},
+		{name: "username", pw: "AnaMaria1", problems: []string{"must not be the username"}},
+		{name: "email", pw: "ana.maria@example.com", problems: []string{"must not be the email address"}},
+		{name: "email local part", pw: "ANA.MARIA", problems: []string{"must not be the email address"}},
+		{name: "common", pw: "Password", problems: []string{"is too common"}},
+		{name: "every problem", pw: "qwerty", problems: []string{"must be at least 8 characters", "is too common"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.pw, "anamaria1", "Ana.Maria@example.com")
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("rejected: %v", err)
+				}
+				return
+			}
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("error %v is not a *PolicyError", err)
+			}
+			if !reflect.DeepEqual(policyErr.Problems, tt.problems) {
+				t.Fatalf("problems %q, want %q", policyErr.Problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckCommonListOff(t *testing.T) {
+	if err := (Policy{MinLength: 8}).Check("password", "", ""); err != nil {
+		t.Fatalf("common password rejected with RejectCommon off: %v", err)
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "0")
+	t.Setenv("PASSWORD_CHECK_COMMON", "false")
+	if got, want := PolicyFromEnv(), (Policy{MinLength: 8}); got != want {
+		t.Fatalf("policy %+v, want %+v", got, want)
+	}
+}
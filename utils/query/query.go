@@ -0,0 +1,278 @@
+// Package query parses the query string of listing endpoints. Problems are
+// collected per parameter so a request gets every mistake in one answer:
+//
+//	p := query.New(c.Request.URL.Query())
+//	page := p.Page(20, 100)
+//	city := p.String("city", "")
+//	if err := p.Err(); err != nil { ... }
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error lists the problems with each invalid parameter.
+type Error struct {
+	Problems map[string][]string
+}
+
+func (e *Error) Error() string {
+	names := make([]string, 0, len(e.Problems))
+	for name := range e.Problems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "invalid query parameters: " + strings.Join(names, ", ")
+}
+
+type Params struct {
+	values   url.Values
+	problems map[string][]string
+}
+
+func New(values url.Values) *Params {
+	return &Params{values: values, problems: map[string][]string{}}
+}
+
+func (p *Params) fail(name string, problem string) {
+	p.problems[name] = append(p.problems[name], problem)
+}
+
+// Err returns an *Error when any parameter was invalid.
+func (p *Params) Err() error {
+	if len(p.problems) == 0 {
+		return nil
+	}
+	return &Error{Problems: p.problems}
+}
+
+// Require records a problem for each absent parameter.
+func (p *Params) Require(names ...string) {
+	for _, name := range names {
+		if p.String(name, "") == "" {
+			p.fail(name, "is required")
+		}
+	}
+}
+
+func (p *Params) String(name string, def string) string {
+	value := strings.TrimSpace(p.values.Get(name))
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// OneOf returns the parameter when it is one of allowed, compared case
+// insensitively, and def when it is absent.
+func (p *Params) OneOf(name string, def string, allowed ...string) string {
+	value := strings.ToLower(p.String(name, ""))
+	if value == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	p.fail(name, "must be one of "+strings.Join(allowed, ", "))
+	return def
+}
+
+func (p *Params) Int(name string, def int, min int, max int) int {
+	raw := p.String(name, "")
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min || value > max {
+		p.fail(name, "must be a number between "+strconv.Itoa(min)+" and "+strconv.Itoa(max))
+		return def
+	}
+	return value
+}
+
+// Uint returns nil when the parameter is absent.
+func (p *Params) Uint(name string) *uint {
+	raw := p.String(name, "")
+	if raw == "" {
+		return nil
+	}
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		p.fail(name, "must be a positive number")
+		return nil
+	}
+	v := uint(value)
+	return &v
+}
+
+// Float returns nil when the parameter is absent.
+func (p *Params) Float(name string) *float64 {
+	raw := p.String(name, "")
+	if raw == "" {
+		return nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		p.fail(name, "must be a number")
+		return nil
+	}
+	return &value
+}
+
+func (p *Params) FloatRange(name string, def float64, min float64, max float64) float64 {
+	raw := p.String(name, "")
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < min || value > max {
+		p.fail(name, "must be a number between "+strconv.FormatFloat(min, 'f', -1, 64)+" and "+strconv.FormatFloat(max, 'f', -1, 64))
+		return def
+	}
+	return value
+}
+
+func (p *Params) Bool(name string) bool {
+	raw := p.String(name, "")
+	if raw == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		p.fail(name, "must be true or false")
+		return false
+	}
+	return value
+}
+
+// Time accepts a date (2006-01-02) or an RFC 3339 time and returns nil when
+// the parameter is absent.
+func (p *Params) Time(name string) *time.Time {
+	raw := p.String(name, "")
+	if raw == "" {
+		return nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return &t
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		p.fail(name, "must be a date (2006-01-02) or an RFC 3339 time")
+		return nil
+	}
+	return &t
+}
+
+// List splits a comma separated parameter.
+func (p *Params) List(name string) []string {
+	list := []string{}
+	for _, item := range strings.Split(p.String(name, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Subset is List restricted to allowed, compared case insensitively, and def
+// when the parameter is absent.
+func (p *Params) Subset(name string, def []string, allowed ...string) []string {
+	if _, ok := p.values[name]; !ok {
+		return def
+	}
+	subset := []string{}
+	for _, item := range p.List(name) {
+		item = strings.ToLower(item)
+		found := false
+		for _, a := range allowed {
+			if item == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.fail(name, item+" is not one of "+strings.Join(allowed, ", "))
+			continue
+		}
+		subset = append(subset, item)
+	}
+	return subset
+}
+
+// Page selects a page by number (page, limit) or, when cursor is set, the
+// page after the cursor a previous response returned.
+type Page struct {
+	Number int
+	Limit  int
+	Cursor string
+}
+
+func (pg Page) Offset() int {
+	return (pg.Number - 1) * pg.Limit
+}
+
+// OffsetPage is Page for listings without cursors.
+func (p *Params) OffsetPage(defaultLimit int, maxLimit int) Page {
+	return Page{
+		Number: p.Int("page", 1, 1, 1<<30),
+		Limit:  p.Int("limit", defaultLimit, 1, maxLimit),
+	}
+}
+
+func (p *Params) Page(defaultLimit int, maxLimit int) Page {
+	page := Page{
+		Number: p.Int("page", 1, 1, 1<<30),
+		Limit:  p.Int("limit", defaultLimit, 1, maxLimit),
+		Cursor: p.String("cursor", ""),
+	}
+	if page.Cursor != "" && p.values.Get("page") != "" {
+		p.fail("cursor", "can not be combined with page")
+	}
+	return page
+}
+
+// Meta is the pagination metadata of a listing response.
+func (pg Page) Meta(total int64, next_cursor string) map[string]any {
+	meta := map[string]any{"limit": pg.Limit, "total": total}
+	if pg.Cursor == "" {
+		meta["page"] = pg.Number
+	}
+	if next_cursor != "" {
+		meta["next_cursor"] = next_cursor
+	}
+	return meta
+}
+
+// EncodeCursor turns the position of the last item into an opaque cursor.
+func EncodeCursor(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// InvalidCursor is the error for a cursor that was not made by this listing.
+func InvalidCursor() error {
+	return &Error{Problems: map[string][]string{"cursor": {"is invalid"}}}
+}
+
+// DecodeCursor reads a cursor made by EncodeCursor into v.
+func DecodeCursor(cursor string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return InvalidCursor()
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return InvalidCursor()
+	}
+	return nil
+}
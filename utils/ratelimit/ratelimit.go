@@ -0,0 +1,69 @@
+// Package ratelimit counts attempts per key in fixed windows. MemoryStore
+// works for a single instance; a shared backend such as Redis only has to
+// implement Store.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Hit records one attempt for key and reports whether it is still within
+	// limit attempts per window.
+	Hit(key string, limit int, window time.Duration) (Result, error)
+}
+
+type counter struct {
+	count   int
+	resetAt time.Time
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Hit(key string, limit int, window time.Duration) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		c = &counter{resetAt: now.Add(window)}
+		s.counters[key] = c
+	}
+	c.count++
+
+	if c.count > limit {
+		return Result{Allowed: false, RetryAfter: c.resetAt.Sub(now)}, nil
+	}
+	return Result{Allowed: true, Remaining: limit - c.count}, nil
+}
+
+// sweep drops expired counters, at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for k, c := range s.counters {
+		if !now.Before(c.resetAt) {
+			delete(s.counters, k)
+		}
+	}
+	s.lastSweep = now
+}
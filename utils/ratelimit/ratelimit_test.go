@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreLimitsPerKey(t *testing.T) {
+	s := NewMemoryStore()
+	for i := 1; i <= 3; i++ {
+		result, err := s.Hit("login:ana", 3, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != 3-i {
+			t.Fatalf("attempt %d: %+v", i, result)
+		}
+	}
+
+	result, _ := s.Hit("login:ana", 3, time.Minute)
+	if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > time.Minute {
+		t.Fatalf("attempt over the limit: %+v", result)
+	}
+
+	if result, _ := s.Hit("login:budi", 3, time.Minute); !result.Allowed {
+		t.Fatal("another key shares the counter")
+	}
+}
+
+func TestMemoryStoreWindowResets(t *testing.T) {
+	s := NewMemoryStore()
+	s.Hit("k", 1, 20*time.Millisecond)
+	if result, _ := s.Hit("k", 1, 20*time.Millisecond); result.Allowed {
+		t.Fatal("second attempt in the window allowed")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if result, _ := s.Hit("k", 1, 20*time.Millisecond); !result.Allowed {
+		t.Fatalf("attempt after the window refused: %+v", result)
+	}
+}
+
+func TestMemoryStoreSweepsExpiredCounters(t *testing.T) {
+	s := NewMemoryStore()
+	s.Hit("old", 1, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	s.lastSweep = time.Now().Add(-time.Minute)
+
+	s.Hit("new", 1, time.Minute)
+	if _, ok := s.counters["old"]; ok {
+		t.Fatal("expired counter kept")
+	}
+	if _, ok := s.counters["new"]; !ok {
+		t.Fatal("current counter dropped")
+	}
+}
@@ -0,0 +1,64 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	ActionVerifyEmail = "verify_email"
+	ActionMFALogin    = "mfa_login"
+)
+
+// ActionClaims authorize one out of band action such as verifying an email.
+// The action is the audience, so an action token is never accepted as an
+// access token and the other way around.
+type ActionClaims struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+	jwt.StandardClaims
+}
+
+func (c *ActionClaims) Valid() error {
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+	if !c.VerifyIssuer(Issuer(), true) {
+		return errors.New("token has an unexpected issuer")
+	}
+	return nil
+}
+
+func GenerateActionToken(action string, user_id uint, email string, lifespan time.Duration) (string, error) {
+	jti, err := GenerateOpaqueToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return sign(&ActionClaims{
+		UserID: user_id,
+		Email:  email,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Issuer:    Issuer(),
+			Audience:  action,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(lifespan).Unix(),
+		},
+	})
+}
+
+func ParseActionToken(action string, tokenString string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || !claims.VerifyAudience(action, true) {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
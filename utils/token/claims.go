@@ -0,0 +1,75 @@
+package token
+
+import (
+	"errors"
+	"final-project/utils"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Claims is the payload of every access token. Subject carries the user id as
+// a string; UserID repeats it as a number for older clients. SessionID ties
+// the token to the login session it was issued for, 0 for none.
+type Claims struct {
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid,omitempty"`
+	jwt.StandardClaims
+}
+
+func Issuer() string {
+	return utils.Getenv("JWT_ISSUER", "final-project")
+}
+
+func Audience() string {
+	return utils.Getenv("JWT_AUDIENCE", "final-project-api")
+}
+
+func NewClaims(user_id uint, role string, session_id uint, lifespan time.Duration) (*Claims, error) {
+	jti, err := GenerateOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Claims{
+		UserID:    user_id,
+		Role:      role,
+		SessionID: session_id,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   strconv.FormatUint(uint64(user_id), 10),
+			Issuer:    Issuer(),
+			Audience:  Audience(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(lifespan).Unix(),
+		},
+	}, nil
+}
+
+// Valid extends the exp/iat/nbf checks of jwt.StandardClaims with iss and aud.
+func (c *Claims) Valid() error {
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+	if !c.VerifyIssuer(Issuer(), true) {
+		return errors.New("token has an unexpected issuer")
+	}
+	if !c.VerifyAudience(Audience(), true) {
+		return errors.New("token has an unexpected audience")
+	}
+	if c.UserID == 0 || c.Subject != strconv.FormatUint(uint64(c.UserID), 10) {
+		return errors.New("token has an invalid subject")
+	}
+	return nil
+}
+
+func (c *Claims) IssuedAtTime() time.Time {
+	return time.Unix(c.IssuedAt, 0)
+}
+
+func (c *Claims) ExpiresAtTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
@@ -0,0 +1,228 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"final-project/utils"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const defaultSecret = "rahasiasekali"
+
+// Key is one entry of the keyring. Private is only set for the signing key.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// Keyring holds the key new tokens are signed with and every key a token may
+// still be verified with. During a rotation the previous public keys stay in
+// Verify until the tokens they signed have expired.
+type Keyring struct {
+	Signing *Key
+	Verify  map[string]*Key
+	// secret is the HS256 secret, empty when HMAC tokens are not accepted.
+	secret        []byte
+	defaultSecret bool
+}
+
+var keys = hmacKeyring(utils.Getenv("API_SECRET", defaultSecret))
+
+func hmacKeyring(secret string) *Keyring {
+	return &Keyring{
+		secret:        []byte(secret),
+		defaultSecret: secret == defaultSecret,
+		Verify:        map[string]*Key{},
+	}
+}
+
+// Init loads the keyring from the environment. It has to run after the .env
+// file is loaded.
+//
+//	JWT_SIGNING_KEY_FILE  PEM encoded RSA or Ed25519 private key used to sign tokens
+//	JWT_VERIFY_KEY_FILES  comma separated PEM files of keys that are still accepted
+//	API_SECRET            HS256 secret, used to sign when no signing key is set
+func Init() error {
+	k, err := LoadKeyring(
+		utils.Getenv("JWT_SIGNING_KEY_FILE", ""),
+		splitList(utils.Getenv("JWT_VERIFY_KEY_FILES", "")),
+		utils.Getenv("API_SECRET", defaultSecret),
+	)
+	if err != nil {
+		return err
+	}
+	keys = k
+	return nil
+}
+
+func LoadKeyring(signingFile string, verifyFiles []string, secret string) (*Keyring, error) {
+	if signingFile == "" {
+		return hmacKeyring(secret), nil
+	}
+
+	k := &Keyring{Verify: map[string]*Key{}}
+	// HS256 tokens stay valid next to the asymmetric keys only when the secret
+	// is a real one; the default secret is public knowledge.
+	if secret != defaultSecret {
+		k.secret = []byte(secret)
+	}
+
+	signing, err := loadKeyFile(signingFile)
+	if err != nil {
+		return nil, err
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("%s: signing key must be a private key", signingFile)
+	}
+	k.Signing = signing
+	k.Verify[signing.ID] = signing
+
+	for _, f := range verifyFiles {
+		v, err := loadKeyFile(f)
+		if err != nil {
+			return nil, err
+		}
+		v.Private = nil
+		if _, ok := k.Verify[v.ID]; !ok {
+			k.Verify[v.ID] = v
+		}
+	}
+	return k, nil
+}
+
+// UsesDefaultSecret reports whether tokens are signed with the built-in HS256
+// secret, which must never happen in production.
+func UsesDefaultSecret() bool {
+	return keys.Signing == nil && keys.defaultSecret
+}
+
+func sign(claims jwt.Claims) (string, error) {
+	if keys.Signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(keys.secret)
+	}
+	token := jwt.NewWithClaims(keys.Signing.Method, claims)
+	token.Header["kid"] = keys.Signing.ID
+	return token.SignedString(keys.Signing.Private)
+}
+
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(keys.secret) == 0 {
+			return nil, errors.New("HMAC signed tokens are not accepted")
+		}
+		return keys.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keys.Verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %v", token.Header["kid"])
+	}
+	if key.Method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set.
+func JWKS() map[string]interface{} {
+	ids := make([]string, 0, len(keys.Verify))
+	for id := range keys.Verify {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := []map[string]string{}
+	for _, id := range ids {
+		k := keys.Verify[id]
+		jwk := map[string]string{"kid": k.ID, "alg": k.Method.Alg(), "use": "sig"}
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set = append(set, jwk)
+	}
+	return map[string]interface{}{"keys": set}
+}
+
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	k := &Key{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k.Private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k.Private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k.Public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		k.Public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch priv := k.Private.(type) {
+	case *rsa.PrivateKey:
+		k.Public = &priv.PublicKey
+	case ed25519.PrivateKey:
+		k.Public = priv.Public()
+	}
+
+	switch k.Public.(type) {
+	case *rsa.PublicKey:
+		k.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%s: only RSA and Ed25519 keys are supported", path)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(k.Public)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sum := sha256.Sum256(der)
+	k.ID = base64.RawURLEncoding.EncodeToString(sum[:12])
+	return k, nil
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
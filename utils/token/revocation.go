@@ -0,0 +1,52 @@
+package token
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationStore keeps the jti of access tokens that must be rejected before
+// they expire. Entries only need to live until the token's own expiry.
+type RevocationStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{entries: map[string]time.Time{}}
+}
+
+func (s *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range s.entries {
+		if exp.Before(now) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exp, ok := s.entries[jti]
+	return ok && exp.After(time.Now()), nil
+}
+
+var revocations RevocationStore = NewMemoryRevocationStore()
+
+// SetRevocationStore replaces the store consulted by TokenValid. It is meant to
+// be called once while wiring the application.
+func SetRevocationStore(s RevocationStore) {
+	revocations = s
+}
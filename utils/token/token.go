@@ -1,8 +1,8 @@
 package token
 
 import (
+	"errors"
 	"final-project/utils"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -11,37 +11,28 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
-var API_SECRET = utils.Getenv("API_SECRET", "rahasiasekali")
+var ErrTokenRevoked = errors.New("token has been revoked")
 
-func GenerateToken(user_id uint) (string, error) {
+func GenerateToken(user_id uint, role string, session_id uint) (string, error) {
 	token_lifespan, err := strconv.Atoi(utils.Getenv("TOKEN_HOUR_LIFESPAN", "1"))
 
 	if err != nil {
 		return "", err
 	}
 
-	claims := jwt.MapClaims{}
-	claims["authorized"] = true
-	claims["user_id"] = user_id
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(token_lifespan)).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	claims, err := NewClaims(user_id, role, session_id, time.Hour*time.Duration(token_lifespan))
 
-	return token.SignedString([]byte(API_SECRET))
+	if err != nil {
+		return "", err
+	}
+
+	return sign(claims)
 
 }
 
 func TokenValid(c *gin.Context) error {
-	tokenString := ExtractToken(c)
-	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(API_SECRET), nil
-	})
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := ExtractClaims(c)
+	return err
 }
 
 func ExtractToken(c *gin.Context) string {
@@ -56,25 +47,46 @@ func ExtractToken(c *gin.Context) string {
 	return ""
 }
 
-func ExtractTokenID(c *gin.Context) (uint, error) {
+// ExtractClaims parses and verifies the request token, including issuer,
+// audience and the revocation check, and returns its claims.
+func ExtractClaims(c *gin.Context) (*Claims, error) {
+	return ParseClaims(ExtractToken(c))
+}
 
-	tokenString := ExtractToken(c)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(API_SECRET), nil
-	})
+func ParseClaims(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		uid, err := strconv.ParseUint(fmt.Sprintf("%.0f", claims["user_id"]), 10, 32)
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if claims.Id != "" {
+		revoked, err := revocations.IsRevoked(claims.Id)
 		if err != nil {
-			return 0, err
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
 		}
-		return uint(uid), nil
 	}
-	return 0, nil
+	return claims, nil
+}
+
+func ExtractTokenID(c *gin.Context) (uint, error) {
+	claims, err := ExtractClaims(c)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// RevokeClaims puts the token described by claims on the revocation list
+// until it expires.
+func RevokeClaims(claims *Claims) error {
+	if claims.Id == "" {
+		return errors.New("token has no jti")
+	}
+	return revocations.Revoke(claims.Id, claims.ExpiresAtTime())
 }